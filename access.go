@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/brunoa19/shipa-github-actions/types"
+)
+
+func reconcileTeams(client *shipa.Client, teams []*shipa.Team) error {
+	for _, team := range teams {
+		if team == nil {
+			continue
+		}
+
+		current, err := client.GetTeam(context.TODO(), team.Name)
+		if err != nil {
+			// team does not exist
+			err = client.CreateTeam(context.TODO(), team)
+			if err != nil {
+				return fmt.Errorf("failed to create shipa team %s: %v", team.Name, err)
+			}
+			fmt.Printf("team %s: created\n", team.Name)
+			continue
+		}
+
+		// tags of a team declared without tags are left untouched, an explicit empty list clears them
+		if team.Tags == nil {
+			continue
+		}
+
+		added, removed := diffStrings(current.Tags, team.Tags)
+		if len(added) > 0 || len(removed) > 0 {
+			err = client.UpdateTeam(context.TODO(), team.Name, &shipa.UpdateTeamRequest{Tags: append([]string{}, team.Tags...)})
+			if err != nil {
+				return fmt.Errorf("failed to update shipa team %s: %v", team.Name, err)
+			}
+			fmt.Printf("team %s: tags updated, added %v, removed %v\n", team.Name, added, removed)
+		}
+	}
+
+	return nil
+}
+
+func reconcileRoles(client *shipa.Client, roles []*types.Role) error {
+	for _, role := range roles {
+		if role == nil {
+			continue
+		}
+
+		current, err := client.GetRole(context.TODO(), role.Name)
+		if err != nil {
+			// role does not exist
+			err = client.CreateRole(context.TODO(), role.ToShipaRole())
+			if err != nil {
+				return fmt.Errorf("failed to create shipa role %s: %v", role.Name, err)
+			}
+			fmt.Printf("role %s: created\n", role.Name)
+		} else if current.Context != role.Context {
+			// shipa has no role update, context can be changed only by recreating the role
+			log.Printf("role %s: context is %q, declared %q, recreate the role to change it\n", role.Name, current.Context, role.Context)
+		}
+
+		err = reconcileRolePermissions(client, role)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// built-in admin roles never lose permissions, losing them may lock everyone out of shipa
+var builtinAdminRoles = map[string]bool{"admin": true}
+
+// permission scheme granting everything
+const permissionAll = "all"
+
+func isAdminRole(name string, permissions []string) bool {
+	if builtinAdminRoles[name] {
+		return true
+	}
+
+	for _, permission := range permissions {
+		if permission == permissionAll {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileRolePermissions makes role permissions match the declared ones,
+// permissions of a role declared without the permissions list are left untouched
+func reconcileRolePermissions(client *shipa.Client, role *types.Role) error {
+	if role.Permissions == nil {
+		return nil
+	}
+
+	current, err := client.GetPermission(context.TODO(), role.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get permissions of shipa role %s: %v", role.Name, err)
+	}
+
+	added, removed := diffStrings(current.Permissions, role.Permissions)
+	if len(removed) > 0 && isAdminRole(role.Name, current.Permissions) {
+		return fmt.Errorf("refusing to remove permissions %v from admin role %s, declare them in the action file", removed, role.Name)
+	}
+
+	if len(added) > 0 {
+		err = client.CreatePermission(context.TODO(), &shipa.Permission{Role: role.Name, Permissions: added})
+		if err != nil {
+			return fmt.Errorf("failed to add permissions to shipa role %s: %v", role.Name, err)
+		}
+	}
+
+	for _, permission := range removed {
+		err = client.DeletePermission(context.TODO(), role.Name, permission)
+		if err != nil {
+			return fmt.Errorf("failed to remove permission %s from shipa role %s: %v", permission, role.Name, err)
+		}
+	}
+
+	if len(added) > 0 || len(removed) > 0 {
+		fmt.Printf("role %s: permissions added %v, removed %v\n", role.Name, added, removed)
+	}
+	return nil
+}
+
+func reconcileRoleBindings(client *shipa.Client, bindings []*types.RoleBinding) error {
+	users, err := client.ListUsers(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to list shipa users: %v", err)
+	}
+
+	byEmail := make(map[string]*shipa.User)
+	for _, user := range users {
+		byEmail[user.Email] = user
+	}
+
+	for _, binding := range bindings {
+		if binding == nil {
+			continue
+		}
+
+		for _, email := range binding.Users {
+			user, ok := byEmail[email]
+			if !ok {
+				return fmt.Errorf("failed to bind shipa role %s: user %s not found", binding.Role, email)
+			}
+
+			if user.HasRole(binding.Role) {
+				continue
+			}
+
+			err = client.AssociateRoleToUser(context.TODO(), binding.Role, email)
+			if err != nil {
+				return fmt.Errorf("failed to bind shipa role %s to user %s: %v", binding.Role, email, err)
+			}
+			fmt.Printf("role %s: bound to user %s\n", binding.Role, email)
+		}
+	}
+
+	return nil
+}
+
+// reconcileUsers invites missing users, undeclared users are removed only when prune is set
+func reconcileUsers(client *shipa.Client, users *types.Users) error {
+	current, err := client.ListUsers(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to list shipa users: %v", err)
+	}
+
+	existing := make(map[string]bool)
+	for _, user := range current {
+		existing[user.Email] = true
+	}
+
+	declared := make(map[string]bool)
+	for _, member := range users.Members {
+		if member == nil {
+			continue
+		}
+		declared[member.Email] = true
+
+		if existing[member.Email] {
+			continue
+		}
+
+		user, err := member.ToShipaUser()
+		if err != nil {
+			return err
+		}
+
+		err = client.CreateUser(context.TODO(), user)
+		if err != nil {
+			return fmt.Errorf("failed to create shipa user %s: %v", member.Email, err)
+		}
+		fmt.Printf("user %s: invited\n", member.Email)
+	}
+
+	if !users.Prune {
+		return nil
+	}
+
+	if len(declared) == 0 {
+		return errors.New("refusing to remove all shipa users, users.members is empty")
+	}
+
+	for _, user := range current {
+		if declared[user.Email] {
+			continue
+		}
+
+		if hasAdminRole(user) {
+			log.Printf("user %s: not declared, but has admin role, skipping removal\n", user.Email)
+			continue
+		}
+
+		err = client.DeleteUser(context.TODO(), user.Email)
+		if err != nil {
+			return fmt.Errorf("failed to delete shipa user %s: %v", user.Email, err)
+		}
+		fmt.Printf("user %s: removed\n", user.Email)
+	}
+
+	return nil
+}
+
+func hasAdminRole(user *shipa.User) bool {
+	for _, role := range user.Roles {
+		if role != nil && builtinAdminRoles[role.Name] {
+			return true
+		}
+	}
+	return false
+}
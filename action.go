@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/brunoa19/shipa-github-actions/shipa"
 	"github.com/brunoa19/shipa-github-actions/types"
@@ -17,24 +19,30 @@ import (
 func main() {
 	shipaActionYml := flag.String("shipa-action", "", "Path to shipa-action.yml")
 	debug := flag.Bool("debug", false, "Enables debug mode")
+	guardrails := flag.String("guardrails", guardrailsAll, "Comma separated guardrails to enforce, 'all' or 'none'")
+	acknowledge := flag.String("acknowledge", "", "Comma separated guardrails acknowledged for this run")
 	flag.Parse()
 
-	if _, ok := os.LookupEnv("SHIPA_HOST"); !ok {
-		log.Fatal("SHIPA_HOST env not set")
+	if flag.NArg() > 0 {
+		err := runCommand(flag.Arg(0), flag.Args()[1:], *debug)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	if _, ok := os.LookupEnv("SHIPA_TOKEN"); !ok {
-		log.Fatal("SHIPA_TOKEN env not set")
+	options, err := newActionOptions(*guardrails, *acknowledge)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	client, err := shipa.New()
+	client, err := newClient(*debug)
 	if err != nil {
-		log.Fatal("failed to create shipa client:", err)
+		log.Fatal(err)
 	}
-	client.SetDebugMode(*debug)
 
 	if *shipaActionYml != "" {
-		err := createShipaAction(client, *shipaActionYml)
+		err := createShipaAction(client, *shipaActionYml, options)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -42,6 +50,24 @@ func main() {
 
 }
 
+func newClient(debug bool) (*shipa.Client, error) {
+	if _, ok := os.LookupEnv("SHIPA_HOST"); !ok {
+		return nil, errors.New("SHIPA_HOST env not set")
+	}
+
+	if _, ok := os.LookupEnv("SHIPA_TOKEN"); !ok {
+		return nil, errors.New("SHIPA_TOKEN env not set")
+	}
+
+	client, err := shipa.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shipa client: %v", err)
+	}
+	client.SetDebugMode(debug)
+
+	return client, nil
+}
+
 func readFile(path string) ([]byte, error) {
 	if _, err := os.Stat(path); err != nil {
 		return nil, fmt.Errorf("invalid file path: %v", err)
@@ -57,25 +83,102 @@ func readFile(path string) ([]byte, error) {
 
 type ShipaAction struct {
 	App           *shipa.CreateAppRequest `yaml:"app,omitempty"`
-	AppEnv        *shipa.CreateAppEnv     `yaml:"app-env,omitempty"`
-	AppCname      *shipa.AppCname         `yaml:"app-cname,omitempty"`
+	AppEnv        *types.AppEnv           `yaml:"app-env,omitempty"`
+	AppCname      *types.AppCname         `yaml:"app-cname,omitempty"`
 	NetworkPolicy *shipa.NetworkPolicy    `yaml:"network-policy,omitempty"`
-	AppDeploy     *shipa.AppDeploy        `yaml:"app-deploy,omitempty"`
+	AppDeploy     *types.AppDeploy        `yaml:"app-deploy,omitempty"`
 	Framework     *shipa.PoolConfig       `yaml:"framework,omitempty"`
 	Cluster       *types.Cluster          `yaml:"cluster,omitempty"`
-	Job           *shipa.JobCreateRequest `yaml:"job,omitempty"`
+	Job           *types.Job              `yaml:"job,omitempty"`
+	Teams         []*shipa.Team           `yaml:"teams,omitempty"`
+	Roles         []*types.Role           `yaml:"roles,omitempty"`
+	RoleBindings  []*types.RoleBinding    `yaml:"role-bindings,omitempty"`
+	Users         *types.Users            `yaml:"users,omitempty"`
 }
 
-func createShipaAction(client *shipa.Client, path string) error {
+func readShipaAction(path string) (*ShipaAction, error) {
 	yamlFile, err := readFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var action ShipaAction
 	err = yaml.Unmarshal(yamlFile, &action)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal: %v", err)
+	}
+
+	return &action, nil
+}
+
+// actionOptions - settings of a shipa-action.yml run taken from command line
+type actionOptions struct {
+	guardrails   map[string]bool
+	acknowledged map[string]bool
+}
+
+func newActionOptions(guardrails, acknowledge string) (*actionOptions, error) {
+	enabled, err := parseGuardrails(guardrails)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -guardrails: %v", err)
+	}
+
+	acknowledged, err := parseGuardrails(acknowledge)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -acknowledge: %v", err)
+	}
+
+	return &actionOptions{
+		guardrails:   enabled,
+		acknowledged: acknowledged,
+	}, nil
+}
+
+func createShipaAction(client *shipa.Client, path string, options *actionOptions) error {
+	action, err := readShipaAction(path)
+	if err != nil {
+		return err
+	}
+
+	if action.Job != nil {
+		action.Job.SetDefaults()
+	}
+
+	if action.AppDeploy != nil {
+		action.AppDeploy.SetDefaults()
+	}
+
+	err = newPreflight(client, action, options).run()
+	if err != nil {
+		return err
+	}
+
+	if len(action.Teams) > 0 {
+		err = reconcileTeams(client, action.Teams)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(action.Roles) > 0 {
+		err = reconcileRoles(client, action.Roles)
+		if err != nil {
+			return err
+		}
+	}
+
+	if action.Users != nil {
+		err = reconcileUsers(client, action.Users)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(action.RoleBindings) > 0 {
+		err = reconcileRoleBindings(client, action.RoleBindings)
+		if err != nil {
+			return err
+		}
 	}
 
 	if action.Framework != nil {
@@ -100,36 +203,44 @@ func createShipaAction(client *shipa.Client, path string) error {
 	}
 
 	if action.AppEnv != nil {
-		err = client.CreateAppEnvs(context.TODO(), action.AppEnv)
+		err = createAppEnvs(client, action.AppEnv)
 		if err != nil {
-			return fmt.Errorf("failed to create shipa app-env: %v", err)
+			return err
 		}
 	}
 
 	if action.AppCname != nil {
-		err = client.CreateAppCname(context.TODO(), action.AppCname)
+		err = reconcileAppCnames(client, action.AppCname)
 		if err != nil {
-			return fmt.Errorf("failed to create shipa app-cname: %v", err)
+			return err
 		}
 	}
 
 	if action.NetworkPolicy != nil {
-		err = client.CreateOrUpdateNetworkPolicy(context.TODO(), action.NetworkPolicy)
+		err = reconcileNetworkPolicy(client, action.NetworkPolicy)
 		if err != nil {
-			return fmt.Errorf("failed to create shipa network-policy: %v", err)
+			return err
 		}
 	}
 
 	if action.AppDeploy != nil {
-		action.AppDeploy.SetDefaults()
-		err = client.DeployApp(context.TODO(), action.AppDeploy)
+		err = deployApp(client, action.AppDeploy)
 		if err != nil {
-			return fmt.Errorf("failed to deploy shipa app: %v", err)
+			return err
 		}
 	}
 
 	if action.Job != nil {
-		err = createJobIfNotExist(client, action.Job)
+		err = resolveJobEnvs(client, &action.Job.JobCreateRequest)
+		if err != nil {
+			return err
+		}
+
+		if action.Job.Run != nil {
+			err = runJob(client, &action.Job.JobCreateRequest, action.Job.Run)
+		} else {
+			err = reconcileJob(client, &action.Job.JobCreateRequest)
+		}
 		if err != nil {
 			return err
 		}
@@ -138,20 +249,66 @@ func createShipaAction(client *shipa.Client, path string) error {
 	return nil
 }
 
-func createJobIfNotExist(client *shipa.Client, job *shipa.JobCreateRequest) error {
-	jobs, err := client.ListJobs(context.TODO())
+// resolveAppEnvs reads env files and decrypts values, returns requests to create app envs
+func resolveAppEnvs(appEnv *types.AppEnv) ([]*shipa.CreateAppEnv, error) {
+	reqs, err := appEnv.ToShipaAppEnvs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse shipa app-env: %v", err)
+	}
+
+	err = decryptAppEnvs(reqs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt shipa app-env: %v", err)
+	}
+	return reqs, nil
+}
+
+func createAppEnvs(client *shipa.Client, appEnv *types.AppEnv) error {
+	reqs, err := resolveAppEnvs(appEnv)
 	if err != nil {
 		return err
 	}
 
-	for _, j := range jobs {
-		if j.Name == job.Name {
-			return nil
+	for _, req := range reqs {
+		err = client.CreateAppEnvs(context.TODO(), req)
+		if err != nil {
+			return fmt.Errorf("failed to create shipa app-env: %v", err)
 		}
 	}
+	return nil
+}
 
-	_, err = client.CreateJob(context.TODO(), job)
-	return err
+// deployApp deploys the app, pre- and post-deploy hooks must succeed
+func deployApp(client *shipa.Client, deploy *types.AppDeploy) error {
+	if deploy.PreDeploy != nil {
+		err := runHookJob(client, deploy.PreDeploy)
+		if err != nil {
+			return fmt.Errorf("pre-deploy hook failed: %v", err)
+		}
+	}
+
+	err := client.DeployApp(context.TODO(), &deploy.AppDeploy)
+	if err != nil {
+		return fmt.Errorf("failed to deploy shipa app: %v", err)
+	}
+
+	if deploy.PostDeploy != nil {
+		err = runHookJob(client, deploy.PostDeploy)
+		if err != nil {
+			return fmt.Errorf("post-deploy hook failed: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func runHookJob(client *shipa.Client, job *types.Job) error {
+	err := resolveJobEnvs(client, &job.JobCreateRequest)
+	if err != nil {
+		return err
+	}
+
+	return runJob(client, &job.JobCreateRequest, job.Run)
 }
 
 func createFrameworkIfNotExist(client *shipa.Client, framework *shipa.PoolConfig) error {
@@ -178,10 +335,30 @@ func createAppIfNotExist(client *shipa.Client, app *shipa.CreateAppRequest) erro
 	return nil
 }
 
-func createClusterIfNotExist(client *shipa.Client, input *types.Cluster) error {
+// resolveCluster converts the declared cluster, reading credential sources, and checks the credentials
+func resolveCluster(input *types.Cluster) (*shipa.Cluster, error) {
 	cluster, err := input.ToShipaCluster()
 	if err != nil {
-		return fmt.Errorf("failed to parse shipa cluster: %v", err)
+		return nil, fmt.Errorf("failed to parse shipa cluster: %v", err)
+	}
+
+	window, err := input.CertExpiryWindow()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse shipa cluster: %v", err)
+	}
+
+	err = types.CheckCredentials(cluster.Endpoint, window, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid shipa cluster credentials: %v", err)
+	}
+
+	return cluster, nil
+}
+
+func createClusterIfNotExist(client *shipa.Client, input *types.Cluster) error {
+	cluster, err := resolveCluster(input)
+	if err != nil {
+		return err
 	}
 
 	shipaCluster, err := client.GetCluster(context.TODO(), cluster.Name)
@@ -194,19 +371,17 @@ func createClusterIfNotExist(client *shipa.Client, input *types.Cluster) error {
 		return nil
 	}
 
-	// check if need to add new frameworks to the cluster
-	newFrameworks := getNewFrameworks(shipaCluster, cluster)
-	if len(newFrameworks) > 0 {
-		if shipaCluster.Resources == nil {
-			shipaCluster.Resources = &shipa.ClusterResources{}
-		}
+	if err != nil {
+		return fmt.Errorf("failed to get shipa cluster: %v", err)
+	}
 
-		for _, name := range newFrameworks {
-			shipaCluster.Resources.Frameworks = append(shipaCluster.Resources.Frameworks, &shipa.Framework{
-				Name: name,
-			})
-		}
+	changes, err := reconcileClusterResources(shipaCluster, cluster, input.PruneFrameworks())
+	if err != nil {
+		return err
+	}
 
+	if len(changes) > 0 {
+		fmt.Printf("cluster %s: %s\n", cluster.Name, strings.Join(changes, ", "))
 		err = client.UpdateCluster(context.TODO(), shipaCluster)
 		if err != nil {
 			return fmt.Errorf("failed to update shipa cluster: %v", err)
@@ -216,12 +391,63 @@ func createClusterIfNotExist(client *shipa.Client, input *types.Cluster) error {
 	return nil
 }
 
+// reconcileClusterResources applies desired frameworks and ingress controllers to the current cluster and returns applied changes
+func reconcileClusterResources(current, desired *shipa.Cluster, pruneFrameworks bool) ([]string, error) {
+	if pruneFrameworks && len(frameworkNames(desired)) == 0 {
+		return nil, fmt.Errorf("refusing to detach all frameworks from shipa cluster %s, resources.frameworks.name is empty", desired.Name)
+	}
+
+	if desired.Resources == nil {
+		return nil, nil
+	}
+
+	if current.Resources == nil {
+		current.Resources = &shipa.ClusterResources{}
+	}
+
+	var changes []string
+	for _, name := range getNewFrameworks(current, desired) {
+		current.Resources.Frameworks = append(current.Resources.Frameworks, &shipa.Framework{
+			Name: name,
+		})
+		changes = append(changes, fmt.Sprintf("framework %s attached", name))
+	}
+
+	if pruneFrameworks {
+		removed := getRemovedFrameworks(current, desired)
+		if len(removed) > 0 {
+			removedSet := make(map[string]bool)
+			for _, name := range removed {
+				removedSet[name] = true
+				changes = append(changes, fmt.Sprintf("framework %s detached", name))
+			}
+
+			var frameworks []*shipa.Framework
+			for _, framework := range current.Resources.Frameworks {
+				if framework != nil && !removedSet[framework.Name] {
+					frameworks = append(frameworks, framework)
+				}
+			}
+			current.Resources.Frameworks = frameworks
+		}
+	}
+
+	if desired.Resources.IngressControllers != nil {
+		controllers, changed := mergeIngressControllers(current.Resources.IngressControllers, desired.Resources.IngressControllers)
+		if changed {
+			current.Resources.IngressControllers = controllers
+			changes = append(changes, "ingress controllers updated")
+		}
+	}
+
+	return changes, nil
+}
+
 func getNewFrameworks(current *shipa.Cluster, newCluster *shipa.Cluster) []string {
 	currentFrameworks := convertFrameworksToMap(current)
-	newFrameworks := convertFrameworksToMap(newCluster)
 
 	var result []string
-	for name, _ := range newFrameworks {
+	for _, name := range frameworkNames(newCluster) {
 		if !currentFrameworks[name] {
 			result = append(result, name)
 		}
@@ -230,6 +456,33 @@ func getNewFrameworks(current *shipa.Cluster, newCluster *shipa.Cluster) []strin
 	return result
 }
 
+func getRemovedFrameworks(current *shipa.Cluster, newCluster *shipa.Cluster) []string {
+	newFrameworks := convertFrameworksToMap(newCluster)
+
+	var result []string
+	for _, name := range frameworkNames(current) {
+		if !newFrameworks[name] {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+func frameworkNames(cluster *shipa.Cluster) []string {
+	if cluster.Resources == nil {
+		return nil
+	}
+
+	var result []string
+	for _, framework := range cluster.Resources.Frameworks {
+		if framework != nil {
+			result = append(result, framework.Name)
+		}
+	}
+	return result
+}
+
 func convertFrameworksToMap(cluster *shipa.Cluster) map[string]bool {
 	if cluster.Resources == nil || cluster.Resources.Frameworks == nil {
 		return nil
@@ -243,3 +496,53 @@ func convertFrameworksToMap(cluster *shipa.Cluster) map[string]bool {
 	}
 	return result
 }
+
+// mergeIngressControllers merges controllers by position, only fields set in the desired controller are applied,
+// so values filled by shipa are kept and do not produce an update
+func mergeIngressControllers(current, desired []*shipa.IngressController) ([]*shipa.IngressController, bool) {
+	changed := len(current) != len(desired)
+	merged := make([]*shipa.IngressController, 0, len(desired))
+	for i, want := range desired {
+		if want == nil {
+			continue
+		}
+
+		got := &shipa.IngressController{}
+		if i < len(current) && current[i] != nil {
+			copied := *current[i]
+			got = &copied
+		} else {
+			changed = true
+		}
+
+		mergeString := func(dst *string, value string) {
+			if value != "" && *dst != value {
+				*dst = value
+				changed = true
+			}
+		}
+		mergeInt := func(dst *int64, value int64) {
+			if value != 0 && *dst != value {
+				*dst = value
+				changed = true
+			}
+		}
+
+		mergeString(&got.IngressIP, want.IngressIP)
+		mergeString(&got.ServiceType, want.ServiceType)
+		mergeString(&got.Type, want.Type)
+		mergeInt(&got.HTTPPort, want.HTTPPort)
+		mergeInt(&got.HTTPSPort, want.HTTPSPort)
+		mergeInt(&got.ProtectedPort, want.ProtectedPort)
+		if got.Debug != want.Debug {
+			got.Debug = want.Debug
+			changed = true
+		}
+		mergeString(&got.AcmeEmail, want.AcmeEmail)
+		mergeString(&got.AcmeServer, want.AcmeServer)
+
+		merged = append(merged, got)
+	}
+
+	return merged, changed
+}
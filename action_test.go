@@ -108,3 +108,55 @@ func Test_createFrameworkIfNotExist(t *testing.T) {
 	err := createFrameworkIfNotExist(client, framework)
 	assert.NoError(t, err)
 }
+
+func Test_reconcileClusterResources(t *testing.T) {
+	newCurrent := func() *shipa.Cluster {
+		return &shipa.Cluster{
+			Resources: &shipa.ClusterResources{
+				Frameworks: []*shipa.Framework{{Name: "dev"}, {Name: "legacy"}},
+				IngressControllers: []*shipa.IngressController{
+					{IngressIP: "10.0.0.1", ServiceType: "LoadBalancer", Type: "traefik", HTTPPort: 80, HTTPSPort: 443, AcmeEmail: "ops@acme.io"},
+				},
+			},
+		}
+	}
+
+	desired := &shipa.Cluster{
+		Resources: &shipa.ClusterResources{
+			Frameworks:         []*shipa.Framework{{Name: "dev"}, {Name: "prod"}},
+			IngressControllers: []*shipa.IngressController{{ServiceType: "LoadBalancer", Type: "traefik"}},
+		},
+	}
+
+	current := newCurrent()
+	changes, err := reconcileClusterResources(current, desired, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"framework prod attached"}, changes)
+	assert.Equal(t, []string{"dev", "legacy", "prod"}, frameworkNames(current))
+
+	current = newCurrent()
+	changes, err = reconcileClusterResources(current, desired, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"framework prod attached", "framework legacy detached"}, changes)
+	assert.Equal(t, []string{"dev", "prod"}, frameworkNames(current))
+
+	desired.Resources.IngressControllers[0].HTTPPort = 8080
+	current = newCurrent()
+	changes, err = reconcileClusterResources(current, desired, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"framework prod attached", "ingress controllers updated"}, changes)
+	assert.Equal(t, []*shipa.IngressController{
+		{IngressIP: "10.0.0.1", ServiceType: "LoadBalancer", Type: "traefik", HTTPPort: 8080, HTTPSPort: 443, AcmeEmail: "ops@acme.io"},
+	}, current.Resources.IngressControllers)
+
+	current = newCurrent()
+	for _, empty := range []*shipa.Cluster{
+		{Name: "gke", Resources: &shipa.ClusterResources{}},
+		{Name: "gke", Resources: &shipa.ClusterResources{Frameworks: []*shipa.Framework{}}},
+		{Name: "gke"},
+	} {
+		_, err = reconcileClusterResources(current, empty, true)
+		assert.EqualError(t, err, "refusing to detach all frameworks from shipa cluster gke, resources.frameworks.name is empty")
+	}
+	assert.Equal(t, []string{"dev", "legacy"}, frameworkNames(current))
+}
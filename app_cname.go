@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/brunoa19/shipa-github-actions/types"
+)
+
+func reconcileAppCnames(client *shipa.Client, input *types.AppCname) error {
+	app, err := client.GetApp(context.TODO(), input.App)
+	if err != nil {
+		return fmt.Errorf("failed to get shipa app: %v", err)
+	}
+
+	current := currentCnameSchemes(app)
+	declared := make(map[string]bool)
+	for _, cname := range input.ToShipaCnames() {
+		declared[cname.Cname] = true
+
+		scheme, ok := current[cname.Cname]
+		if !ok {
+			err = client.CreateAppCname(context.TODO(), cname)
+			if err != nil {
+				return fmt.Errorf("failed to create shipa app-cname %s: %v", cname.Cname, err)
+			}
+			fmt.Printf("app-cname: added %s (%s)\n", cname.Cname, cname.Scheme)
+			continue
+		}
+
+		wantScheme := "http"
+		if cname.Encrypted {
+			wantScheme = "https"
+		}
+
+		// without an entrypoint the live scheme is unknown, so the declared one is applied
+		if scheme != wantScheme {
+			if scheme == "" {
+				scheme = "unknown"
+			}
+			err = client.UpdateAppCname(context.TODO(), cname)
+			if err != nil {
+				return fmt.Errorf("failed to update shipa app-cname %s: %v", cname.Cname, err)
+			}
+			fmt.Printf("app-cname: updated %s (%s -> %s)\n", cname.Cname, scheme, cname.Scheme)
+		}
+	}
+
+	if !input.Prune() {
+		return nil
+	}
+
+	var undeclared []string
+	for _, cname := range app.Cname {
+		if !declared[cname] {
+			undeclared = append(undeclared, cname)
+		}
+	}
+
+	if len(undeclared) > 0 {
+		err = client.DeleteAppCname(context.TODO(), &shipa.DeleteCnameRequest{
+			App:   input.App,
+			Cname: undeclared,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete shipa app-cname: %v", err)
+		}
+		fmt.Printf("app-cname: removed %v\n", undeclared)
+	}
+
+	return nil
+}
+
+// currentCnameSchemes returns app cnames with their schemes taken from entrypoints, scheme is empty when unknown
+func currentCnameSchemes(app *shipa.App) map[string]string {
+	result := make(map[string]string)
+	for _, cname := range app.Cname {
+		result[cname] = ""
+	}
+
+	for _, entrypoint := range app.Entrypoints {
+		if entrypoint == nil {
+			continue
+		}
+		if _, ok := result[entrypoint.Cname]; ok {
+			result[entrypoint.Cname] = entrypoint.Scheme
+		}
+	}
+
+	return result
+}
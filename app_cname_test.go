@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_currentCnameSchemes(t *testing.T) {
+	app := &shipa.App{
+		Cname: []string{"api.acme.io", "www.acme.io"},
+		Entrypoints: []*shipa.Entrypoint{
+			{Cname: "api.acme.io", Scheme: "https"},
+			{Cname: "api.shipa.cloud", Scheme: "http"},
+			nil,
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"api.acme.io": "https",
+		"www.acme.io": "",
+	}, currentCnameSchemes(app))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+const (
+	outputCSV = "csv"
+
+	roleContextTeam = "team"
+)
+
+// auditReport - who can do what: user -> roles -> permission schemes
+type auditReport struct {
+	GeneratedAt string        `json:"generatedAt"`
+	Entries     []*auditEntry `json:"entries"`
+	Teams       []*shipa.Team `json:"teams"`
+}
+
+// auditEntry - single role assignment of a user, part of auditReport
+type auditEntry struct {
+	User         string   `json:"user"`
+	Role         string   `json:"role,omitempty"`
+	ContextType  string   `json:"contextType,omitempty"`
+	ContextValue string   `json:"contextValue,omitempty"`
+	TeamTags     []string `json:"teamTags,omitempty"`
+	Permissions  []string `json:"permissions"`
+}
+
+func auditCommand(args []string, debug bool) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	output := fs.String("output", outputCSV, "Output format: csv or json")
+	file := fs.String("file", "", "Write report to file instead of stdout")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *output != outputCSV && *output != outputJSON {
+		return fmt.Errorf("unknown output format: %s", *output)
+	}
+
+	client, err := newClient(debug)
+	if err != nil {
+		return err
+	}
+
+	report, err := buildAuditReport(client)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *output == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return writeAuditCSV(w, report)
+}
+
+func buildAuditReport(client *shipa.Client) (*auditReport, error) {
+	users, err := client.ListUsers(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shipa users: %v", err)
+	}
+
+	roles, err := client.ListRoles(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shipa roles: %v", err)
+	}
+
+	teams, err := client.ListTeams(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shipa teams: %v", err)
+	}
+
+	permissions := make(map[string][]string)
+	for _, role := range roles {
+		permission, err := client.GetPermission(context.TODO(), role.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get permissions of shipa role %s: %v", role.Name, err)
+		}
+		permissions[role.Name] = sortedStrings(permission.Permissions)
+	}
+
+	teamTags := make(map[string][]string)
+	for _, team := range teams {
+		teamTags[team.Name] = team.Tags
+	}
+
+	report := &auditReport{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Teams:       teams,
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Email < users[j].Email })
+	for _, user := range users {
+		if len(user.Roles) == 0 {
+			report.Entries = append(report.Entries, &auditEntry{User: user.Email})
+			continue
+		}
+
+		for _, role := range user.Roles {
+			if role == nil {
+				continue
+			}
+
+			entry := &auditEntry{
+				User:         user.Email,
+				Role:         role.Name,
+				ContextType:  role.ContextType,
+				ContextValue: role.ContextValue,
+				Permissions:  permissions[role.Name],
+			}
+			if role.ContextType == roleContextTeam {
+				entry.TeamTags = teamTags[role.ContextValue]
+			}
+			report.Entries = append(report.Entries, entry)
+		}
+	}
+
+	return report, nil
+}
+
+// writeAuditCSV writes one row per permission, so the report can be filtered in a spreadsheet
+func writeAuditCSV(w io.Writer, report *auditReport) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"user", "role", "context_type", "context_value", "team_tags", "permission"})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range report.Entries {
+		permissions := entry.Permissions
+		if len(permissions) == 0 {
+			permissions = []string{""}
+		}
+
+		for _, permission := range permissions {
+			err = cw.Write([]string{
+				entry.User,
+				entry.Role,
+				entry.ContextType,
+				entry.ContextValue,
+				strings.Join(entry.TeamTags, ";"),
+				permission,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of listing commands
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+func clustersCommand(args []string, debug bool) error {
+	fs := flag.NewFlagSet("clusters", flag.ExitOnError)
+	output := fs.String("output", outputTable, "Output format: table, json or yaml")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(debug)
+	if err != nil {
+		return err
+	}
+
+	clusters, err := client.ListClusters(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to list shipa clusters: %v", err)
+	}
+
+	// credentials are never printed
+	for _, cluster := range clusters {
+		if cluster.Endpoint != nil {
+			cluster.Endpoint = &shipa.ClusterEndpoint{Addresses: cluster.Endpoint.Addresses}
+		}
+	}
+
+	switch *output {
+	case outputTable:
+		return printClustersTable(clusters)
+	case outputJSON, outputYAML:
+		return printObject(*output, clusters)
+	default:
+		return fmt.Errorf("unknown output format: %s", *output)
+	}
+}
+
+func printClustersTable(clusters []*shipa.Cluster) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tADDRESSES\tFRAMEWORKS\tINGRESS CONTROLLERS")
+	for _, cluster := range clusters {
+		var addresses, ingressControllers []string
+		if cluster.Endpoint != nil {
+			addresses = cluster.Endpoint.Addresses
+		}
+
+		if cluster.Resources != nil {
+			for _, ingress := range cluster.Resources.IngressControllers {
+				if ingress == nil {
+					continue
+				}
+				description := fmt.Sprintf("%s/%s", ingress.Type, ingress.ServiceType)
+				if ingress.IngressIP != "" {
+					description += " " + ingress.IngressIP
+				}
+				ingressControllers = append(ingressControllers, description)
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			cluster.Name,
+			strings.Join(addresses, ","),
+			strings.Join(frameworkNames(cluster), ","),
+			strings.Join(ingressControllers, ","))
+	}
+
+	return w.Flush()
+}
+
+func printObject(format string, obj interface{}) error {
+	var data []byte
+	var err error
+	if format == outputJSON {
+		data, err = json.MarshalIndent(obj, "", "  ")
+		if err == nil {
+			data = append(data, '\n')
+		}
+	} else {
+		data, err = yaml.Marshal(obj)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %v", err)
+	}
+
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+)
+
+func runCommand(name string, args []string, debug bool) error {
+	switch name {
+	case "encrypt":
+		return encryptCommand(args)
+	case "generate-key":
+		return generateKeyCommand(args)
+	case "audit":
+		return auditCommand(args, debug)
+	case "clusters":
+		return clustersCommand(args, debug)
+	case "jobs":
+		return jobsCommand(args, debug)
+	case "users":
+		return usersCommand(args, debug)
+	case "render-network-policy":
+		return renderNetworkPolicyCommand(args)
+	default:
+		return fmt.Errorf("unknown command: %s", name)
+	}
+}
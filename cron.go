@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+var cronMacros = map[string]bool{
+	"@yearly":   true,
+	"@annually": true,
+	"@monthly":  true,
+	"@weekly":   true,
+	"@daily":    true,
+	"@midnight": true,
+	"@hourly":   true,
+}
+
+// validateCron checks standard 5-field cron expression as accepted by kubernetes CronJob
+func validateCron(expr string) error {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@") {
+		if !cronMacros[expr] {
+			return fmt.Errorf("unknown macro %q", expr)
+		}
+		return nil
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("expected %d fields (minute hour day-of-month month day-of-week), got %d", len(cronFields), len(fields))
+	}
+
+	for i, field := range fields {
+		if err := cronFields[i].validate(field); err != nil {
+			return fmt.Errorf("%s: %v", cronFields[i].name, err)
+		}
+	}
+	return nil
+}
+
+func (f cronField) validate(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if err := f.validateItem(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f cronField) validateItem(item string) error {
+	rangePart := item
+	if idx := strings.Index(item, "/"); idx >= 0 {
+		rangePart = item[:idx]
+		step, err := strconv.Atoi(item[idx+1:])
+		if err != nil || step <= 0 {
+			return fmt.Errorf("invalid step in %q", item)
+		}
+	}
+
+	if rangePart == "*" || (rangePart == "?" && (f.name == "day of month" || f.name == "day of week")) {
+		return nil
+	}
+
+	bounds := strings.SplitN(rangePart, "-", 2)
+	start, err := f.parseValue(bounds[0])
+	if err != nil {
+		return err
+	}
+
+	if len(bounds) == 2 {
+		end, err := f.parseValue(bounds[1])
+		if err != nil {
+			return err
+		}
+		if end < start {
+			return fmt.Errorf("invalid range %q", rangePart)
+		}
+	}
+	return nil
+}
+
+func (f cronField) parseValue(value string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(value, name) {
+			if f.name == "month" {
+				return i + 1, nil
+			}
+			return i, nil
+		}
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", n, f.min, f.max)
+	}
+	return n, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateCron(t *testing.T) {
+	valid := []string{
+		"*/5 * * * *",
+		"0 3 * * 1-5",
+		"30 2 1,15 JAN-MAR sun",
+		"0 0 ? * MON",
+		"@daily",
+	}
+	for _, expr := range valid {
+		assert.NoError(t, validateCron(expr), expr)
+	}
+
+	invalid := []string{
+		"* * * *",
+		"60 * * * *",
+		"0 24 * * *",
+		"*/0 * * * *",
+		"0 0 0 * *",
+		"5-1 * * * *",
+		"0 0 * FOO *",
+		"@every 5m",
+	}
+	for _, expr := range invalid {
+		assert.Error(t, validateCron(expr), expr)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/brunoa19/shipa-github-actions/envcrypt"
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+// decryptAppEnvs replaces encrypted values in place, the key is loaded only if there is something to decrypt
+func decryptAppEnvs(reqs []*shipa.CreateAppEnv) error {
+	var key []byte
+	for _, req := range reqs {
+		for _, env := range req.Envs {
+			if !envcrypt.IsEncrypted(env.Value) {
+				continue
+			}
+
+			if key == nil {
+				var err error
+				key, err = envcrypt.LoadKey()
+				if err != nil {
+					return err
+				}
+			}
+
+			value, err := envcrypt.Decrypt(key, env.Value)
+			if err != nil {
+				return fmt.Errorf("env %s: %v", env.Name, err)
+			}
+			env.Value = value
+		}
+	}
+
+	return nil
+}
+
+func encryptCommand(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	value := fs.String("value", "", "Value to encrypt, read from stdin when empty")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	key, err := envcrypt.LoadKey()
+	if err != nil {
+		return err
+	}
+
+	plaintext := *value
+	if plaintext == "" {
+		plaintext, err = readStdin()
+		if err != nil {
+			return err
+		}
+	}
+
+	encrypted, err := envcrypt.Encrypt(key, plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt value: %v", err)
+	}
+
+	fmt.Println(encrypted)
+	return nil
+}
+
+func generateKeyCommand(args []string) error {
+	fs := flag.NewFlagSet("generate-key", flag.ExitOnError)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	key, err := envcrypt.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %v", err)
+	}
+
+	fmt.Println(key)
+	return nil
+}
+
+func readStdin() (string, error) {
+	data, err := ioutil.ReadAll(bufio.NewReader(os.Stdin))
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %v", err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
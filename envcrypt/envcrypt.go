@@ -0,0 +1,132 @@
+package envcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Prefix marks encrypted values
+const Prefix = "encrypted:v1:"
+
+// Key sources
+const (
+	EnvKey     = "SHIPA_ENV_KEY"
+	EnvKeyFile = "SHIPA_ENV_KEY_FILE"
+)
+
+const keySize = 32
+
+var (
+	// ErrNoKey - uses when encrypted value found, but key is not configured
+	ErrNoKey = fmt.Errorf("encryption key not set, use %s or %s env", EnvKey, EnvKeyFile)
+)
+
+// IsEncrypted - checks if value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// GenerateKey - returns new random key encoded in base64
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadKey - reads key from SHIPA_ENV_KEY or from file set in SHIPA_ENV_KEY_FILE
+func LoadKey() ([]byte, error) {
+	encoded, ok := os.LookupEnv(EnvKey)
+	if !ok {
+		path, ok := os.LookupEnv(EnvKeyFile)
+		if !ok {
+			return nil, ErrNoKey
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %v", err)
+		}
+		encoded = string(data)
+	}
+
+	return ParseKey(encoded)
+}
+
+// ParseKey - decodes base64 key
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid key: expected %d bytes, got %d", keySize, len(key))
+	}
+
+	return key, nil
+}
+
+// Encrypt - encrypts value with AES-256-GCM, result has Prefix
+func Encrypt(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return Prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt - decrypts value produced by Encrypt
+func Decrypt(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value is not encrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %v", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt value: wrong key or corrupted data")
+	}
+
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,28 @@
+package envcrypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EncryptDecrypt(t *testing.T) {
+	encoded, err := GenerateKey()
+	assert.NoError(t, err)
+
+	key, err := ParseKey(encoded)
+	assert.NoError(t, err)
+
+	encrypted, err := Encrypt(key, "s3cr3t")
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+
+	decrypted, err := Decrypt(key, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", decrypted)
+
+	otherEncoded, _ := GenerateKey()
+	otherKey, _ := ParseKey(otherEncoded)
+	_, err = Decrypt(otherKey, encrypted)
+	assert.EqualError(t, err, "failed to decrypt value: wrong key or corrupted data")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+// Guardrails refusing risky network policy changes unless acknowledged
+const (
+	guardrailIngressAllowAll    = "ingress-allow-all"
+	guardrailEgressOpenPeers    = "egress-open-peers"
+	guardrailDisableAppPolicies = "disable-app-policies"
+
+	guardrailsAll  = "all"
+	guardrailsNone = "none"
+)
+
+var knownGuardrails = []string{
+	guardrailIngressAllowAll,
+	guardrailEgressOpenPeers,
+	guardrailDisableAppPolicies,
+}
+
+func parseGuardrails(value string) (map[string]bool, error) {
+	result := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "", guardrailsNone:
+		case guardrailsAll:
+			for _, g := range knownGuardrails {
+				result[g] = true
+			}
+		case guardrailIngressAllowAll, guardrailEgressOpenPeers, guardrailDisableAppPolicies:
+			result[name] = true
+		default:
+			return nil, fmt.Errorf("unknown guardrail %q, expected one of %v", name, knownGuardrails)
+		}
+	}
+	return result, nil
+}
+
+// violates returns true when guardrail is enforced and was not acknowledged for this run
+func (o *actionOptions) violates(guardrail string) bool {
+	if o == nil {
+		return false
+	}
+	return o.guardrails[guardrail] && !o.acknowledged[guardrail]
+}
+
+func (p *preflight) checkGuardrails() error {
+	var violations []string
+	report := func(guardrail, msg string) {
+		if p.options.violates(guardrail) {
+			violations = append(violations, fmt.Sprintf("guardrail %s: %s", guardrail, msg))
+		}
+	}
+
+	if policy := p.action.NetworkPolicy; policy != nil {
+		current, err := p.client.GetNetworkPolicy(context.TODO(), policy.App)
+		if err != nil {
+			p.lookupFailed("network-policy", policy.App, err)
+			current = &shipa.NetworkPolicy{}
+		}
+
+		if switchesToAllowAll(current.Ingress, policy.Ingress) {
+			report(guardrailIngressAllowAll, fmt.Sprintf("network-policy of app %s switches ingress to %s", policy.App, policyModeAllowAll))
+		}
+		if switchesToAllowAll(current.Egress, policy.Egress) {
+			report(guardrailEgressOpenPeers, fmt.Sprintf("network-policy of app %s switches egress to %s", policy.App, policyModeAllowAll))
+		}
+		if cidrs := newOpenPeers(current.Egress, policy.Egress); len(cidrs) > 0 {
+			report(guardrailEgressOpenPeers, fmt.Sprintf("network-policy of app %s adds egress peers %v", policy.App, cidrs))
+		}
+	}
+
+	// existing frameworks are not updated, so only a framework about to be created can introduce a risky policy
+	if framework := p.action.Framework; framework != nil && p.liveFramework(framework.Name) == nil &&
+		framework.Resources != nil && framework.Resources.General != nil && framework.Resources.General.NetworkPolicy != nil {
+		policy := framework.Resources.General.NetworkPolicy
+
+		if switchesToAllowAll(nil, policy.Ingress) {
+			report(guardrailIngressAllowAll, fmt.Sprintf("framework %s sets ingress to %s", framework.Name, policyModeAllowAll))
+		}
+		if switchesToAllowAll(nil, policy.Egress) {
+			report(guardrailEgressOpenPeers, fmt.Sprintf("framework %s sets egress to %s", framework.Name, policyModeAllowAll))
+		}
+		if cidrs := newOpenPeers(nil, policy.Egress); len(cidrs) > 0 {
+			report(guardrailEgressOpenPeers, fmt.Sprintf("framework %s adds egress peers %v", framework.Name, cidrs))
+		}
+		if policy.DisableAppPolicies {
+			report(guardrailDisableAppPolicies, fmt.Sprintf("framework %s sets disableAppPolicies", framework.Name))
+		}
+	}
+
+	if len(violations) > 0 {
+		violations = append(violations, "rerun with -acknowledge=<guardrail> to apply these changes")
+		return errors.New(strings.Join(violations, "\n  "))
+	}
+	return nil
+}
+
+func switchesToAllowAll(current, desired *shipa.NetworkPolicyConfig) bool {
+	if desired == nil || desired.PolicyMode != policyModeAllowAll {
+		return false
+	}
+	return current == nil || current.PolicyMode != policyModeAllowAll
+}
+
+// newOpenPeers returns open CIDRs present in desired egress rules, but not in the current ones
+func newOpenPeers(current, desired *shipa.NetworkPolicyConfig) []string {
+	existing := openPeers(current)
+	var result []string
+	for cidr := range openPeers(desired) {
+		if !existing[cidr] {
+			result = append(result, cidr)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func openPeers(config *shipa.NetworkPolicyConfig) map[string]bool {
+	result := make(map[string]bool)
+	if config == nil {
+		return result
+	}
+
+	for _, rule := range config.CustomRules {
+		if rule == nil {
+			continue
+		}
+		for _, peer := range rule.Peers {
+			if peer == nil {
+				continue
+			}
+			for _, cidr := range peer.IPBlock {
+				if isOpenCIDR(cidr) {
+					result[cidr] = true
+				}
+			}
+		}
+	}
+	return result
+}
+
+// isOpenCIDR returns true for CIDRs matching every address, e.g. 0.0.0.0/0 or ::0/0
+func isOpenCIDR(cidr string) bool {
+	_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+	if err != nil {
+		return false
+	}
+	ones, _ := network.Mask.Size()
+	return ones == 0
+}
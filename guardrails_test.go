@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseGuardrails(t *testing.T) {
+	all := map[string]bool{
+		guardrailIngressAllowAll:    true,
+		guardrailEgressOpenPeers:    true,
+		guardrailDisableAppPolicies: true,
+	}
+
+	tests := []struct {
+		value   string
+		want    map[string]bool
+		wantErr string
+	}{
+		{value: "", want: map[string]bool{}},
+		{value: "none", want: map[string]bool{}},
+		{value: "all", want: all},
+		{value: " egress-open-peers, ingress-allow-all ", want: map[string]bool{guardrailEgressOpenPeers: true, guardrailIngressAllowAll: true}},
+		{value: "egress-open-peers,unknown", wantErr: `unknown guardrail "unknown", expected one of [ingress-allow-all egress-open-peers disable-app-policies]`},
+	}
+
+	for _, tt := range tests {
+		got, err := parseGuardrails(tt.value)
+		if tt.wantErr != "" {
+			assert.EqualError(t, err, tt.wantErr, tt.value)
+			continue
+		}
+		assert.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+}
+
+func Test_switchesToAllowAll(t *testing.T) {
+	allowAll := &shipa.NetworkPolicyConfig{PolicyMode: policyModeAllowAll}
+	denyAll := &shipa.NetworkPolicyConfig{PolicyMode: policyModeDenyAll}
+
+	assert.True(t, switchesToAllowAll(nil, allowAll))
+	assert.True(t, switchesToAllowAll(denyAll, allowAll))
+	assert.False(t, switchesToAllowAll(allowAll, allowAll))
+	assert.False(t, switchesToAllowAll(allowAll, denyAll))
+	assert.False(t, switchesToAllowAll(allowAll, nil))
+}
+
+func Test_newOpenPeers(t *testing.T) {
+	egress := func(cidrs ...string) *shipa.NetworkPolicyConfig {
+		return &shipa.NetworkPolicyConfig{
+			PolicyMode: policyModeCustomRulesOnly,
+			CustomRules: []*shipa.NetworkPolicyRule{
+				{Enabled: true, Peers: []*shipa.NetworkPeer{{IPBlock: cidrs}}},
+			},
+		}
+	}
+
+	assert.Equal(t, []string{"0.0.0.0/0", "::/0"}, newOpenPeers(nil, egress("10.0.0.0/8", "::/0", "0.0.0.0/0")))
+	assert.Equal(t, []string{"::/0"}, newOpenPeers(egress("0.0.0.0/0"), egress("0.0.0.0/0", "::/0")))
+	assert.Empty(t, newOpenPeers(egress("0.0.0.0/0"), egress("0.0.0.0/0")))
+	assert.Empty(t, newOpenPeers(nil, egress("10.0.0.0/8")))
+	assert.Empty(t, newOpenPeers(egress("0.0.0.0/0"), nil))
+	assert.Equal(t, []string{"0.0.0.0/0", "::0/0"}, newOpenPeers(egress("::/0"), egress("::0/0", "0.0.0.0/0", "not-a-cidr")))
+}
+
+func Test_isOpenCIDR(t *testing.T) {
+	assert.True(t, isOpenCIDR("0.0.0.0/0"))
+	assert.True(t, isOpenCIDR("::/0"))
+	assert.True(t, isOpenCIDR("::0/0"))
+	assert.True(t, isOpenCIDR("10.0.0.0/0"))
+	assert.False(t, isOpenCIDR("0.0.0.0/1"))
+	assert.False(t, isOpenCIDR("10.0.0.0/8"))
+	assert.False(t, isOpenCIDR("0.0.0.0"))
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/brunoa19/shipa-github-actions/types"
+)
+
+// reconcileJob creates the job, or replaces it when the live job differs from the declared one
+func reconcileJob(client *shipa.Client, job *shipa.JobCreateRequest) error {
+	jobs, err := client.ListJobs(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to list shipa jobs: %v", err)
+	}
+
+	for _, j := range jobs {
+		if j.Name != job.Name {
+			continue
+		}
+
+		diff := diffJob(j, job)
+		if len(diff) == 0 {
+			return nil
+		}
+
+		fmt.Printf("job %s: replacing, changes:\n  %s\n", job.Name, strings.Join(diff, "\n  "))
+		err = client.DeleteJob(context.TODO(), j.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete shipa job: %v", err)
+		}
+		break
+	}
+
+	_, err = client.CreateJob(context.TODO(), job)
+	if err != nil {
+		return fmt.Errorf("failed to create shipa job: %v", err)
+	}
+	return nil
+}
+
+// diffJob compares declared job with the live one, optional fields are compared only when declared,
+// so defaults filled by shipa do not cause a replacement
+func diffJob(current *shipa.Job, desired *shipa.JobCreateRequest) []string {
+	var diff []string
+	addDiff := func(field string, before, after interface{}) {
+		diff = append(diff, fmt.Sprintf("%s: %v -> %v", field, before, after))
+	}
+	compareString := func(field, before, after string) {
+		if after != "" && before != after {
+			addDiff(field, fmt.Sprintf("%q", before), fmt.Sprintf("%q", after))
+		}
+	}
+	// these fields are sent even when zero, so they are always compared
+	compareInt := func(field string, before, after int64) {
+		if before != after {
+			addDiff(field, before, after)
+		}
+	}
+	compareLimit := func(field string, before, after *int64) {
+		if after == nil {
+			return
+		}
+		if before == nil {
+			addDiff(field, "<unset>", *after)
+		} else if *before != *after {
+			addDiff(field, *before, *after)
+		}
+	}
+
+	compareString("framework", current.Framework, desired.Framework)
+	compareString("team", current.Team, desired.Team)
+	compareString("description", current.Description, desired.Description)
+	compareString("type", current.Type, desired.Type)
+	compareString("version", current.Version, desired.Version)
+	compareInt("backoffLimit", current.BackoffLimit, desired.BackoffLimit)
+	compareInt("completions", current.Completions, desired.Completions)
+	compareInt("parallelism", current.Parallelism, desired.Parallelism)
+	compareString("schedule", current.Schedule, desired.Schedule)
+	compareString("concurrencyPolicy", current.ConcurrencyPolicy, desired.ConcurrencyPolicy)
+	compareLimit("successfulJobsHistoryLimit", current.SuccessfulJobsHistoryLimit, desired.SuccessfulJobsHistoryLimit)
+	compareLimit("failedJobsHistoryLimit", current.FailedJobsHistoryLimit, desired.FailedJobsHistoryLimit)
+	if current.Suspend != desired.Suspend {
+		addDiff("suspend", current.Suspend, desired.Suspend)
+	}
+
+	if desired.Policy != nil {
+		var before string
+		if current.Policy != nil {
+			before = current.Policy.RestartPolicy
+		}
+		compareString("policy.restartPolicy", before, desired.Policy.RestartPolicy)
+	}
+
+	diff = append(diff, diffJobContainers(current.Containers, desired.Containers)...)
+
+	return diff
+}
+
+// diffJobContainers matches containers by name, fields of a container are compared only when declared
+func diffJobContainers(current, desired []*shipa.JobContainer) []string {
+	var diff []string
+	live := make(map[string]*shipa.JobContainer)
+	for _, c := range current {
+		if c != nil {
+			live[c.Name] = c
+		}
+	}
+
+	declared := make(map[string]bool)
+	for _, d := range desired {
+		if d == nil {
+			continue
+		}
+		declared[d.Name] = true
+		field := fmt.Sprintf("containers[%s]", d.Name)
+
+		c, ok := live[d.Name]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("%s: added", field))
+			continue
+		}
+
+		if d.Image != "" && c.Image != d.Image {
+			diff = append(diff, fmt.Sprintf("%s.image: %q -> %q", field, c.Image, d.Image))
+		}
+		if len(d.Command) > 0 && toJSON(c.Command) != toJSON(d.Command) {
+			diff = append(diff, fmt.Sprintf("%s.command: %s -> %s", field, toJSON(c.Command), toJSON(d.Command)))
+		}
+		if len(d.Args) > 0 && toJSON(c.Args) != toJSON(d.Args) {
+			diff = append(diff, fmt.Sprintf("%s.args: %s -> %s", field, toJSON(c.Args), toJSON(d.Args)))
+		}
+		if d.WorkingDir != "" && c.WorkingDir != d.WorkingDir {
+			diff = append(diff, fmt.Sprintf("%s.workingDir: %q -> %q", field, c.WorkingDir, d.WorkingDir))
+		}
+
+		liveEnvs := make(map[string]string)
+		for _, env := range c.Env {
+			if env != nil {
+				liveEnvs[env.Name] = env.Value
+			}
+		}
+		for _, env := range d.Env {
+			if env == nil {
+				continue
+			}
+			value, ok := liveEnvs[env.Name]
+			if !ok {
+				diff = append(diff, fmt.Sprintf("%s.env.%s: added", field, env.Name))
+			} else if value != env.Value {
+				// values may be secrets, do not print them
+				diff = append(diff, fmt.Sprintf("%s.env.%s: changed", field, env.Name))
+			}
+		}
+
+		if d.Resources != nil {
+			var requests, limits map[string]string
+			if c.Resources != nil {
+				requests, limits = c.Resources.Requests, c.Resources.Limits
+			}
+			diff = append(diff, diffQuantities(field+".resources.requests", requests, d.Resources.Requests)...)
+			diff = append(diff, diffQuantities(field+".resources.limits", limits, d.Resources.Limits)...)
+		}
+
+		if len(d.VolumeMounts) > 0 && toJSON(c.VolumeMounts) != toJSON(d.VolumeMounts) {
+			diff = append(diff, fmt.Sprintf("%s.volumeMounts: %s -> %s", field, toJSON(c.VolumeMounts), toJSON(d.VolumeMounts)))
+		}
+	}
+
+	for _, c := range current {
+		if c != nil && !declared[c.Name] {
+			diff = append(diff, fmt.Sprintf("containers[%s]: removed", c.Name))
+		}
+	}
+
+	return diff
+}
+
+func diffQuantities(field string, current, desired map[string]string) []string {
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diff []string
+	for _, name := range names {
+		if before, after := current[name], desired[name]; before != after {
+			diff = append(diff, fmt.Sprintf("%s.%s: %q -> %q", field, name, before, after))
+		}
+	}
+	return diff
+}
+
+func toJSON(obj interface{}) string {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Sprintf("%+v", obj)
+	}
+	return string(data)
+}
+
+// runJob creates a fresh job, waits until it completes or fails, and optionally deletes it afterwards
+func runJob(client *shipa.Client, req *shipa.JobCreateRequest, run *types.JobRun) error {
+	timeout, pollInterval, err := run.Durations()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// jobs from previous runs hold the name
+	jobs, err := client.ListJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list shipa jobs: %v", err)
+	}
+	for _, j := range jobs {
+		if j.Name == req.Name {
+			err = client.DeleteJob(ctx, j.ID)
+			if err != nil {
+				return fmt.Errorf("failed to delete previous run of shipa job: %v", err)
+			}
+		}
+	}
+
+	job, err := client.CreateJob(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to create shipa job: %v", err)
+	}
+	fmt.Printf("job %s: created\n", req.Name)
+
+	if job.ID == "" {
+		job, err = findJob(ctx, client, req.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	result := waitForJob(ctx, client, job.ID, req, pollInterval)
+
+	if run.Delete {
+		// the run context may be already expired
+		err = client.DeleteJob(context.TODO(), job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete shipa job: %v", err)
+		}
+		fmt.Printf("job %s: deleted\n", req.Name)
+	}
+
+	return result
+}
+
+func waitForJob(ctx context.Context, client *shipa.Client, id string, req *shipa.JobCreateRequest, pollInterval time.Duration) error {
+	var lastState string
+	for {
+		job, err := client.GetJob(ctx, id)
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("failed to get shipa job: %v", err)
+		}
+
+		if job != nil {
+			state := jobState(job)
+			if state != lastState {
+				fmt.Printf("job %s: %s\n", req.Name, state)
+				lastState = state
+			}
+
+			done, err := jobFinished(job, req)
+			if done {
+				if err != nil {
+					return fmt.Errorf("job %s failed: %v", req.Name, err)
+				}
+				fmt.Printf("job %s: succeeded\n", req.Name)
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("job %s did not finish in time, last state: %s", req.Name, lastState)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func jobState(job *shipa.Job) string {
+	if job.Status == nil {
+		return "pending"
+	}
+	return fmt.Sprintf("active=%d succeeded=%d failed=%d", job.Status.Active, job.Status.Succeeded, job.Status.Failed)
+}
+
+// jobFinished returns true when job completed, the error is set when it failed.
+// backoffLimit is taken from the request, it is sent as is, so 0 means no retries
+func jobFinished(job *shipa.Job, req *shipa.JobCreateRequest) (bool, error) {
+	status := job.Status
+	if status == nil {
+		return false, nil
+	}
+
+	if done, err := jobConditionFinished(status); done {
+		return done, err
+	}
+
+	completions := job.Completions
+	if completions == 0 {
+		completions = 1
+	}
+	if status.Succeeded >= completions {
+		return true, nil
+	}
+
+	backoffLimit := req.BackoffLimit
+	if status.Failed > backoffLimit {
+		return true, fmt.Errorf("backoff limit %d exceeded, %d pods failed", backoffLimit, status.Failed)
+	}
+
+	return false, nil
+}
+
+// jobConditionFinished returns true when Complete or Failed condition is set, the error is set when it failed
+func jobConditionFinished(status *shipa.JobStatus) (bool, error) {
+	for _, condition := range status.Conditions {
+		if condition == nil || condition.Status != "True" {
+			continue
+		}
+
+		switch condition.Type {
+		case "Complete":
+			return true, nil
+		case "Failed":
+			return true, fmt.Errorf("%s %s", condition.Reason, condition.Message)
+		}
+	}
+	return false, nil
+}
+
+func findJob(ctx context.Context, client *shipa.Client, name string) (*shipa.Job, error) {
+	jobs, err := client.ListJobs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shipa jobs: %v", err)
+	}
+
+	for _, j := range jobs {
+		if j.Name == name {
+			return j, nil
+		}
+	}
+	return nil, fmt.Errorf("shipa job %s not found", name)
+}
+
+var jobConcurrencyPolicies = map[string]bool{"Allow": true, "Forbid": true, "Replace": true}
+
+// validateJob checks job locally before it is sent to shipa
+func validateJob(field string, job *types.Job) []string {
+	var msgs []string
+	switch job.Type {
+	case "", shipa.JobTypeJob:
+		if job.Schedule != "" && job.Type == shipa.JobTypeJob {
+			msgs = append(msgs, fmt.Sprintf("%s.schedule: is set, but type is %q, use %q", field, job.Type, shipa.JobTypeCronJob))
+		}
+	case shipa.JobTypeCronJob:
+		if job.Schedule == "" {
+			msgs = append(msgs, fmt.Sprintf("%s.schedule: required for cron jobs", field))
+		}
+	default:
+		msgs = append(msgs, fmt.Sprintf("%s.type: unknown type %q, expected %q or %q", field, job.Type, shipa.JobTypeJob, shipa.JobTypeCronJob))
+	}
+
+	if job.Schedule != "" {
+		if err := validateCron(job.Schedule); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s.schedule: invalid cron expression %q: %v", field, job.Schedule, err))
+		}
+
+		if job.Run != nil {
+			msgs = append(msgs, fmt.Sprintf("%s.run: cron jobs can not be awaited", field))
+		}
+	}
+
+	if job.ConcurrencyPolicy != "" && !jobConcurrencyPolicies[job.ConcurrencyPolicy] {
+		msgs = append(msgs, fmt.Sprintf("%s.concurrencyPolicy: unknown policy %q, expected Allow, Forbid or Replace", field, job.ConcurrencyPolicy))
+	}
+
+	for i, container := range job.Containers {
+		if container != nil {
+			msgs = append(msgs, validateJobContainer(fmt.Sprintf("%s.containers[%d]", field, i), container)...)
+		}
+	}
+
+	if job.SuccessfulJobsHistoryLimit != nil && *job.SuccessfulJobsHistoryLimit < 0 {
+		msgs = append(msgs, fmt.Sprintf("%s.successfulJobsHistoryLimit: must not be negative", field))
+	}
+	if job.FailedJobsHistoryLimit != nil && *job.FailedJobsHistoryLimit < 0 {
+		msgs = append(msgs, fmt.Sprintf("%s.failedJobsHistoryLimit: must not be negative", field))
+	}
+
+	return msgs
+}
+
+var resourceQuantity = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$`)
+
+func validateJobContainer(field string, container *shipa.JobContainer) []string {
+	var msgs []string
+	if container.Name == "" {
+		msgs = append(msgs, fmt.Sprintf("%s.name: required", field))
+	}
+	if container.Image == "" {
+		msgs = append(msgs, fmt.Sprintf("%s.image: required", field))
+	}
+
+	for i, env := range container.Env {
+		if env == nil {
+			continue
+		}
+		envField := fmt.Sprintf("%s.env[%d]", field, i)
+		if env.Name == "" {
+			msgs = append(msgs, fmt.Sprintf("%s.name: required", envField))
+		}
+		if env.FromAppEnv != nil {
+			if env.Value != "" {
+				msgs = append(msgs, fmt.Sprintf("%s: value and fromAppEnv are mutually exclusive", envField))
+			}
+			if env.FromAppEnv.App == "" || env.FromAppEnv.Name == "" {
+				msgs = append(msgs, fmt.Sprintf("%s.fromAppEnv: app and name are required", envField))
+			}
+		}
+	}
+
+	if container.Resources != nil {
+		for _, resources := range []struct {
+			name   string
+			values map[string]string
+		}{
+			{"requests", container.Resources.Requests},
+			{"limits", container.Resources.Limits},
+		} {
+			for name, value := range resources.values {
+				if !resourceQuantity.MatchString(value) {
+					msgs = append(msgs, fmt.Sprintf("%s.resources.%s.%s: invalid quantity %q", field, resources.name, name, value))
+				}
+			}
+		}
+	}
+
+	for i, mount := range container.VolumeMounts {
+		if mount != nil && (mount.Name == "" || mount.MountPath == "") {
+			msgs = append(msgs, fmt.Sprintf("%s.volumeMounts[%d]: name and mountPath are required", field, i))
+		}
+	}
+
+	return msgs
+}
+
+// resolveJobEnvs sets values of envs referencing app envs, only public app envs can be referenced
+func resolveJobEnvs(client *shipa.Client, req *shipa.JobCreateRequest) error {
+	appEnvs := make(map[string][]*shipa.AppEnv)
+	for _, container := range req.Containers {
+		if container == nil {
+			continue
+		}
+
+		for _, env := range container.Env {
+			if env == nil || env.FromAppEnv == nil {
+				continue
+			}
+
+			ref := env.FromAppEnv
+			envs, ok := appEnvs[ref.App]
+			if !ok {
+				var err error
+				envs, err = client.GetAppEnvs(context.TODO(), ref.App)
+				if err != nil {
+					return fmt.Errorf("failed to get envs of shipa app %s: %v", ref.App, err)
+				}
+				appEnvs[ref.App] = envs
+			}
+
+			found := false
+			for _, appEnv := range envs {
+				if appEnv.Name == ref.Name {
+					if appEnv.Masked() {
+						return fmt.Errorf("container %s env %s: env %s of app %s is private, shipa does not expose its value, only public envs can be referenced",
+							container.Name, env.Name, ref.Name, ref.App)
+					}
+					env.Value = appEnv.Value
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("container %s env %s: app %s has no env %s", container.Name, env.Name, ref.App, ref.Name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"path"
+	"time"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+func jobsCommand(args []string, debug bool) error {
+	if len(args) == 0 {
+		return errors.New("jobs: subcommand required, available: prune")
+	}
+
+	switch args[0] {
+	case "prune":
+		return jobsPruneCommand(args[1:], debug)
+	default:
+		return fmt.Errorf("jobs: unknown subcommand: %s", args[0])
+	}
+}
+
+// jobFilter - selects jobs for pruning, empty fields match everything
+type jobFilter struct {
+	namePattern string
+	team        string
+	framework   string
+	olderThan   time.Duration
+
+	// by default only finished jobs are pruned
+	includeActive bool
+}
+
+func jobsPruneCommand(args []string, debug bool) error {
+	fs := flag.NewFlagSet("jobs prune", flag.ExitOnError)
+	filter := &jobFilter{}
+	fs.StringVar(&filter.namePattern, "name", "", "Job name pattern, e.g. 'migrate-*'")
+	fs.StringVar(&filter.team, "team", "", "Job team")
+	fs.StringVar(&filter.framework, "framework", "", "Job framework")
+	fs.DurationVar(&filter.olderThan, "older-than", 0, "Minimal job age, e.g. 72h")
+	fs.BoolVar(&filter.includeActive, "include-active", false, "Also delete jobs that are running or have not finished yet")
+	dryRun := fs.Bool("dry-run", false, "Print matching jobs without deleting them")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if filter.namePattern == "" && filter.team == "" && filter.framework == "" && filter.olderThan == 0 {
+		return errors.New("jobs prune: at least one of -name, -team, -framework or -older-than is required")
+	}
+
+	if _, err = path.Match(filter.namePattern, ""); err != nil {
+		return fmt.Errorf("jobs prune: invalid -name pattern: %v", err)
+	}
+
+	client, err := newClient(debug)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := client.ListJobs(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to list shipa jobs: %v", err)
+	}
+
+	now := time.Now()
+	deleted := 0
+	for _, job := range jobs {
+		if !filter.matches(job, now) {
+			continue
+		}
+
+		if *dryRun {
+			fmt.Printf("would delete job %s (id: %s, team: %s, framework: %s, created: %s)\n",
+				job.Name, job.ID, job.Team, job.Framework, job.CreatedAt)
+			continue
+		}
+
+		err = client.DeleteJob(context.TODO(), job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete shipa job %s: %v", job.Name, err)
+		}
+		fmt.Printf("deleted job %s (id: %s)\n", job.Name, job.ID)
+		deleted++
+	}
+
+	if !*dryRun {
+		fmt.Printf("%d jobs deleted\n", deleted)
+	}
+	return nil
+}
+
+func (f *jobFilter) matches(job *shipa.Job, now time.Time) bool {
+	if !f.includeActive && !jobCompleted(job) {
+		return false
+	}
+
+	if f.namePattern != "" {
+		if ok, _ := path.Match(f.namePattern, job.Name); !ok {
+			return false
+		}
+	}
+
+	if f.team != "" && job.Team != f.team {
+		return false
+	}
+
+	if f.framework != "" && job.Framework != f.framework {
+		return false
+	}
+
+	if f.olderThan > 0 {
+		createdAt, err := time.Parse(time.RFC3339, job.CreatedAt)
+		if err != nil {
+			log.Printf("skipping job %s: can not parse createdAt %q: %v\n", job.Name, job.CreatedAt, err)
+			return false
+		}
+
+		if now.Sub(createdAt) < f.olderThan {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jobCompleted returns true when job succeeded or failed, completionTime is set by k8s only for succeeded jobs
+func jobCompleted(job *shipa.Job) bool {
+	if job.Status == nil || job.Status.Active > 0 {
+		return false
+	}
+
+	done, _ := jobConditionFinished(job.Status)
+	return done || job.Status.CompletionTime != ""
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_jobFilter_matches(t *testing.T) {
+	now := time.Date(2021, 6, 10, 12, 0, 0, 0, time.UTC)
+	finished := &shipa.JobStatus{Succeeded: 1, CompletionTime: "2021-06-08T12:05:00Z"}
+	newJob := func(name, createdAt string, status *shipa.JobStatus) *shipa.Job {
+		return &shipa.Job{Name: name, Team: "dev", Framework: "ci", CreatedAt: createdAt, Status: status}
+	}
+
+	tests := []struct {
+		name   string
+		filter jobFilter
+		job    *shipa.Job
+		want   bool
+	}{
+		{"name match", jobFilter{namePattern: "migrate-*"}, newJob("migrate-42", "", finished), true},
+		{"name mismatch", jobFilter{namePattern: "migrate-*"}, newJob("seed-42", "", finished), false},
+		{"team mismatch", jobFilter{team: "ops"}, newJob("migrate-42", "", finished), false},
+		{"framework match", jobFilter{framework: "ci", team: "dev"}, newJob("migrate-42", "", finished), true},
+		{"framework mismatch", jobFilter{framework: "prod"}, newJob("migrate-42", "", finished), false},
+		{"old enough", jobFilter{olderThan: 24 * time.Hour}, newJob("migrate-42", "2021-06-08T12:00:00Z", finished), true},
+		{"too young", jobFilter{olderThan: 72 * time.Hour}, newJob("migrate-42", "2021-06-08T12:00:00Z", finished), false},
+		{"unparsable createdAt", jobFilter{olderThan: time.Hour}, newJob("migrate-42", "yesterday", finished), false},
+		{"failed", jobFilter{olderThan: time.Hour}, newJob("migrate-42", "2021-06-08T12:00:00Z", &shipa.JobStatus{
+			Failed:     1,
+			Conditions: []*shipa.JobCondition{{Type: "Failed", Status: "True", Reason: "BackoffLimitExceeded"}},
+		}), true},
+		{"failed pod, job retrying", jobFilter{olderThan: time.Hour}, newJob("migrate-42", "2021-06-08T12:00:00Z", &shipa.JobStatus{Failed: 1}), false},
+		{"running", jobFilter{olderThan: time.Hour}, newJob("migrate-42", "2021-06-08T12:00:00Z", &shipa.JobStatus{Active: 1}), false},
+		{"no status", jobFilter{olderThan: time.Hour}, newJob("migrate-42", "2021-06-08T12:00:00Z", nil), false},
+		{"running, include active", jobFilter{olderThan: time.Hour, includeActive: true}, newJob("migrate-42", "2021-06-08T12:00:00Z", &shipa.JobStatus{Active: 1}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.matches(tt.job, now))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_diffJob(t *testing.T) {
+	live := &shipa.Job{
+		Name:         "migrate",
+		Framework:    "dev",
+		Team:         "dev",
+		Type:         shipa.JobTypeJob,
+		BackoffLimit: 6,
+		Completions:  1,
+		Parallelism:  1,
+		Policy:       &shipa.JobPolicy{RestartPolicy: "Never"},
+		Containers: []*shipa.JobContainer{
+			{
+				Name:       "migrate",
+				Image:      "acme/api:v1",
+				Command:    []string{},
+				WorkingDir: "/app",
+				Env: []*shipa.JobEnv{
+					{Name: "DB_URL", Value: "postgres://db"},
+					{Name: "INJECTED", Value: "by-shipa"},
+				},
+				Resources: &shipa.JobResources{
+					Requests: map[string]string{"cpu": "100m", "memory": "64Mi"},
+				},
+			},
+		},
+	}
+
+	desired := &shipa.JobCreateRequest{
+		Name:         "migrate",
+		Framework:    "dev",
+		BackoffLimit: 6,
+		Completions:  1,
+		Parallelism:  1,
+		Policy:       &shipa.JobPolicy{RestartPolicy: "Never"},
+		Containers: []*shipa.JobContainer{
+			{
+				Name:  "migrate",
+				Image: "acme/api:v1",
+				Env:   []*shipa.JobEnv{{Name: "DB_URL", Value: "postgres://db"}},
+				Resources: &shipa.JobResources{
+					Requests: map[string]string{"cpu": "100m"},
+				},
+			},
+		},
+	}
+	assert.Empty(t, diffJob(live, desired))
+
+	desired.Containers[0].Image = "acme/api:v2"
+	desired.Containers[0].Command = []string{"migrate", "up"}
+	desired.Containers[0].Env[0].Value = "postgres://other"
+	desired.Containers[0].Resources.Limits = map[string]string{"memory": "128Mi"}
+	desired.BackoffLimit = 0
+	desired.Completions = 2
+	assert.Equal(t, []string{
+		"backoffLimit: 6 -> 0",
+		"completions: 1 -> 2",
+		`containers[migrate].image: "acme/api:v1" -> "acme/api:v2"`,
+		`containers[migrate].command: [] -> ["migrate","up"]`,
+		"containers[migrate].env.DB_URL: changed",
+		`containers[migrate].resources.limits.memory: "" -> "128Mi"`,
+	}, diffJob(live, desired))
+
+	desired = &shipa.JobCreateRequest{
+		Name:         "migrate",
+		BackoffLimit: 6,
+		Completions:  1,
+		Parallelism:  1,
+		Containers:   []*shipa.JobContainer{{Name: "seed", Image: "acme/api:v1"}},
+	}
+	assert.Equal(t, []string{
+		"containers[seed]: added",
+		"containers[migrate]: removed",
+	}, diffJob(live, desired))
+}
+
+func Test_jobFinished(t *testing.T) {
+	req := &shipa.JobCreateRequest{Name: "migrate"}
+	newJob := func(completions int64, status *shipa.JobStatus) *shipa.Job {
+		return &shipa.Job{Name: "migrate", Completions: completions, Status: status}
+	}
+
+	done, err := jobFinished(newJob(1, nil), req)
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	done, err = jobFinished(newJob(1, &shipa.JobStatus{Active: 1}), req)
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	done, err = jobFinished(newJob(1, &shipa.JobStatus{Conditions: []*shipa.JobCondition{{Type: "Complete", Status: "True"}}}), req)
+	assert.True(t, done)
+	assert.NoError(t, err)
+
+	done, err = jobFinished(newJob(1, &shipa.JobStatus{Conditions: []*shipa.JobCondition{
+		{Type: "Complete", Status: "False"},
+		{Type: "Failed", Status: "True", Reason: "DeadlineExceeded", Message: "job was active longer than specified deadline"},
+	}}), req)
+	assert.True(t, done)
+	assert.EqualError(t, err, "DeadlineExceeded job was active longer than specified deadline")
+
+	done, err = jobFinished(newJob(2, &shipa.JobStatus{Succeeded: 1, Active: 1}), req)
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	done, err = jobFinished(newJob(2, &shipa.JobStatus{Succeeded: 2}), req)
+	assert.True(t, done)
+	assert.NoError(t, err)
+
+	// backoffLimit 0 means no retries
+	done, err = jobFinished(newJob(1, &shipa.JobStatus{Failed: 1}), req)
+	assert.True(t, done)
+	assert.EqualError(t, err, "backoff limit 0 exceeded, 1 pods failed")
+
+	req.BackoffLimit = 2
+	done, err = jobFinished(newJob(1, &shipa.JobStatus{Failed: 2, Active: 1}), req)
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	done, err = jobFinished(newJob(1, &shipa.JobStatus{Failed: 3}), req)
+	assert.True(t, done)
+	assert.EqualError(t, err, "backoff limit 2 exceeded, 3 pods failed")
+}
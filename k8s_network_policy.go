@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"gopkg.in/yaml.v2"
+)
+
+// Labels shipa sets on app pods
+const (
+	k8sAppLabel       = "shipa.io/app-name"
+	k8sFrameworkLabel = "shipa.io/pool"
+)
+
+// k8sNetworkPolicy - networking.k8s.io/v1 NetworkPolicy, only fields shipa policies map to
+type k8sNetworkPolicy struct {
+	APIVersion string               `yaml:"apiVersion"`
+	Kind       string               `yaml:"kind"`
+	Metadata   k8sObjectMeta        `yaml:"metadata"`
+	Spec       k8sNetworkPolicySpec `yaml:"spec"`
+}
+
+type k8sObjectMeta struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+type k8sNetworkPolicySpec struct {
+	PodSelector k8sLabelSelector        `yaml:"podSelector"`
+	PolicyTypes []string                `yaml:"policyTypes"`
+	Ingress     []*k8sNetworkPolicyRule `yaml:"ingress,omitempty"`
+	Egress      []*k8sNetworkPolicyRule `yaml:"egress,omitempty"`
+}
+
+type k8sNetworkPolicyRule struct {
+	Ports []*k8sNetworkPolicyPort `yaml:"ports,omitempty"`
+	From  []*k8sNetworkPolicyPeer `yaml:"from,omitempty"`
+	To    []*k8sNetworkPolicyPeer `yaml:"to,omitempty"`
+}
+
+type k8sNetworkPolicyPort struct {
+	Protocol string `yaml:"protocol,omitempty"`
+	Port     int    `yaml:"port,omitempty"`
+}
+
+type k8sNetworkPolicyPeer struct {
+	PodSelector       *k8sLabelSelector `yaml:"podSelector,omitempty"`
+	NamespaceSelector *k8sLabelSelector `yaml:"namespaceSelector,omitempty"`
+	IPBlock           *k8sIPBlock       `yaml:"ipBlock,omitempty"`
+}
+
+type k8sLabelSelector struct {
+	MatchLabels      map[string]string              `yaml:"matchLabels,omitempty"`
+	MatchExpressions []*k8sLabelSelectorRequirement `yaml:"matchExpressions,omitempty"`
+}
+
+type k8sLabelSelectorRequirement struct {
+	Key      string   `yaml:"key"`
+	Operator string   `yaml:"operator"`
+	Values   []string `yaml:"values,omitempty"`
+}
+
+type k8sIPBlock struct {
+	CIDR string `yaml:"cidr"`
+}
+
+func renderNetworkPolicyCommand(args []string) error {
+	fs := flag.NewFlagSet("render-network-policy", flag.ExitOnError)
+	shipaActionYml := fs.String("shipa-action", "", "Path to shipa-action.yml")
+	namespace := fs.String("namespace", "", "Namespace set in rendered manifests")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *shipaActionYml == "" {
+		return errors.New("-shipa-action is required")
+	}
+
+	action, err := readShipaAction(*shipaActionYml)
+	if err != nil {
+		return err
+	}
+
+	var policies []*k8sNetworkPolicy
+	if action.NetworkPolicy != nil {
+		policies = append(policies, appNetworkPolicyToK8s(action.NetworkPolicy, *namespace))
+	}
+
+	if framework := action.Framework; framework != nil && framework.Resources != nil &&
+		framework.Resources.General != nil && framework.Resources.General.NetworkPolicy != nil {
+		ns := *namespace
+		if ns == "" && framework.Resources.General.Setup != nil {
+			ns = framework.Resources.General.Setup.KubernetesNamespace
+		}
+		policies = append(policies, frameworkNetworkPolicyToK8s(framework.Name, framework.Resources.General.NetworkPolicy, ns))
+	}
+
+	if len(policies) == 0 {
+		return errors.New("no network-policy or framework networkPolicy found in the action file")
+	}
+
+	out, err := marshalK8sNetworkPolicies(policies)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+	return nil
+}
+
+func marshalK8sNetworkPolicies(policies []*k8sNetworkPolicy) (string, error) {
+	var docs []string
+	for _, policy := range policies {
+		data, err := yaml.Marshal(policy)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal network policy: %v", err)
+		}
+		docs = append(docs, string(data))
+	}
+
+	return strings.Join(docs, "---\n"), nil
+}
+
+func appNetworkPolicyToK8s(policy *shipa.NetworkPolicy, namespace string) *k8sNetworkPolicy {
+	return newK8sNetworkPolicy(
+		fmt.Sprintf("shipa-app-%s", policy.App),
+		namespace,
+		map[string]string{k8sAppLabel: policy.App},
+		policy.Ingress,
+		policy.Egress,
+	)
+}
+
+func frameworkNetworkPolicyToK8s(name string, policy *shipa.PoolNetworkPolicy, namespace string) *k8sNetworkPolicy {
+	return newK8sNetworkPolicy(
+		fmt.Sprintf("shipa-framework-%s", name),
+		namespace,
+		map[string]string{k8sFrameworkLabel: name},
+		policy.Ingress,
+		policy.Egress,
+	)
+}
+
+func newK8sNetworkPolicy(name, namespace string, podLabels map[string]string, ingress, egress *shipa.NetworkPolicyConfig) *k8sNetworkPolicy {
+	policy := &k8sNetworkPolicy{
+		APIVersion: "networking.k8s.io/v1",
+		Kind:       "NetworkPolicy",
+		Metadata: k8sObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: k8sNetworkPolicySpec{
+			PodSelector: k8sLabelSelector{MatchLabels: podLabels},
+			PolicyTypes: []string{},
+		},
+	}
+
+	if ingress != nil {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, "Ingress")
+		policy.Spec.Ingress = networkPolicyConfigToK8sRules(name+".ingress", ingress, true)
+	}
+
+	if egress != nil {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, "Egress")
+		policy.Spec.Egress = networkPolicyConfigToK8sRules(name+".egress", egress, false)
+	}
+
+	return policy
+}
+
+func networkPolicyConfigToK8sRules(field string, config *shipa.NetworkPolicyConfig, ingress bool) []*k8sNetworkPolicyRule {
+	switch config.PolicyMode {
+	case policyModeAllowAll:
+		// an empty rule allows all traffic
+		return []*k8sNetworkPolicyRule{{}}
+	case policyModeDenyAll:
+		return nil
+	}
+
+	// built-in shipa rules are defined by shipa, so the rendered policy is stricter than the applied one
+	for _, name := range config.ShipaRulesEnabled {
+		log.Printf("%s: warning: shipa rule %s is enabled, but not rendered, the applied policy also allows its traffic\n", field, name)
+	}
+
+	var rules []*k8sNetworkPolicyRule
+	for i, rule := range config.CustomRules {
+		if rule == nil {
+			continue
+		}
+
+		if !rule.Enabled {
+			log.Printf("%s: skipping disabled rule %s\n", field, ruleName(rule, i))
+			continue
+		}
+
+		k8sRule := &k8sNetworkPolicyRule{}
+		for _, port := range rule.Ports {
+			if port == nil {
+				continue
+			}
+			protocol := strings.ToUpper(port.Protocol)
+			if protocol == "" {
+				protocol = "TCP"
+			}
+			k8sRule.Ports = append(k8sRule.Ports, &k8sNetworkPolicyPort{Protocol: protocol, Port: port.Port})
+		}
+
+		peers := networkPeersToK8s(rule)
+		if ingress {
+			k8sRule.From = peers
+		} else {
+			k8sRule.To = peers
+		}
+		rules = append(rules, k8sRule)
+	}
+
+	return rules
+}
+
+func networkPeersToK8s(rule *shipa.NetworkPolicyRule) []*k8sNetworkPolicyPeer {
+	var peers []*k8sNetworkPolicyPeer
+	for _, peer := range rule.Peers {
+		if peer == nil {
+			continue
+		}
+
+		if peer.PodSelector != nil || peer.NamespaceSelector != nil {
+			peers = append(peers, &k8sNetworkPolicyPeer{
+				PodSelector:       labelSelectorToK8s(peer.PodSelector),
+				NamespaceSelector: labelSelectorToK8s(peer.NamespaceSelector),
+			})
+		}
+
+		for _, cidr := range peer.IPBlock {
+			peers = append(peers, &k8sNetworkPolicyPeer{IPBlock: &k8sIPBlock{CIDR: cidr}})
+		}
+	}
+
+	// apps and frameworks may live in any namespace
+	for _, app := range rule.AllowedApps {
+		peers = append(peers, &k8sNetworkPolicyPeer{
+			PodSelector:       &k8sLabelSelector{MatchLabels: map[string]string{k8sAppLabel: app}},
+			NamespaceSelector: &k8sLabelSelector{},
+		})
+	}
+
+	for _, framework := range rule.AllowedPools {
+		peers = append(peers, &k8sNetworkPolicyPeer{
+			PodSelector:       &k8sLabelSelector{MatchLabels: map[string]string{k8sFrameworkLabel: framework}},
+			NamespaceSelector: &k8sLabelSelector{},
+		})
+	}
+
+	return peers
+}
+
+func labelSelectorToK8s(selector *shipa.NetworkPeerSelector) *k8sLabelSelector {
+	if selector == nil {
+		return nil
+	}
+
+	result := &k8sLabelSelector{MatchLabels: selector.MatchLabels}
+	for _, expr := range selector.MatchExpressions {
+		if expr != nil {
+			result.MatchExpressions = append(result.MatchExpressions, &k8sLabelSelectorRequirement{
+				Key:      expr.Key,
+				Operator: expr.Operator,
+				Values:   expr.Values,
+			})
+		}
+	}
+	return result
+}
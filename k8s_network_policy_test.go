@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_marshalK8sNetworkPolicies(t *testing.T) {
+	app := &shipa.NetworkPolicy{
+		App: "api",
+		Ingress: &shipa.NetworkPolicyConfig{
+			PolicyMode:        policyModeCustomRulesOnly,
+			ShipaRulesEnabled: []string{"allow-ingress-controller"},
+			CustomRules: []*shipa.NetworkPolicyRule{
+				{
+					Enabled: true,
+					Ports:   []*shipa.NetworkPort{{Port: 8080}, {Protocol: "udp", Port: 53}},
+					Peers: []*shipa.NetworkPeer{
+						{
+							PodSelector: &shipa.NetworkPeerSelector{
+								MatchLabels: map[string]string{"role": "frontend"},
+								MatchExpressions: []*shipa.SelectorExpression{
+									{Key: "tier", Operator: "In", Values: []string{"web"}},
+								},
+							},
+							NamespaceSelector: &shipa.NetworkPeerSelector{MatchLabels: map[string]string{"team": "web"}},
+							IPBlock:           []string{"10.0.0.0/8", "192.168.0.0/16"},
+						},
+					},
+					AllowedApps:  []string{"admin"},
+					AllowedPools: []string{"dev"},
+				},
+				{
+					Enabled:     false,
+					Description: "disabled",
+					Ports:       []*shipa.NetworkPort{{Port: 22}},
+				},
+			},
+		},
+		Egress: &shipa.NetworkPolicyConfig{
+			PolicyMode: policyModeCustomRulesOnly,
+			CustomRules: []*shipa.NetworkPolicyRule{
+				{Enabled: true, Peers: []*shipa.NetworkPeer{{IPBlock: []string{"0.0.0.0/0"}}}},
+			},
+		},
+	}
+
+	framework := &shipa.PoolNetworkPolicy{
+		Ingress: &shipa.NetworkPolicyConfig{PolicyMode: policyModeAllowAll},
+		Egress:  &shipa.NetworkPolicyConfig{PolicyMode: policyModeDenyAll},
+	}
+
+	out, err := marshalK8sNetworkPolicies([]*k8sNetworkPolicy{
+		appNetworkPolicyToK8s(app, "shipa-dev"),
+		frameworkNetworkPolicyToK8s("dev", framework, ""),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: shipa-app-api
+  namespace: shipa-dev
+spec:
+  podSelector:
+    matchLabels:
+      shipa.io/app-name: api
+  policyTypes:
+  - Ingress
+  - Egress
+  ingress:
+  - ports:
+    - protocol: TCP
+      port: 8080
+    - protocol: UDP
+      port: 53
+    from:
+    - podSelector:
+        matchLabels:
+          role: frontend
+        matchExpressions:
+        - key: tier
+          operator: In
+          values:
+          - web
+      namespaceSelector:
+        matchLabels:
+          team: web
+    - ipBlock:
+        cidr: 10.0.0.0/8
+    - ipBlock:
+        cidr: 192.168.0.0/16
+    - podSelector:
+        matchLabels:
+          shipa.io/app-name: admin
+      namespaceSelector: {}
+    - podSelector:
+        matchLabels:
+          shipa.io/pool: dev
+      namespaceSelector: {}
+  egress:
+  - to:
+    - ipBlock:
+        cidr: 0.0.0.0/0
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: shipa-framework-dev
+spec:
+  podSelector:
+    matchLabels:
+      shipa.io/pool: dev
+  policyTypes:
+  - Ingress
+  - Egress
+  ingress:
+  - {}
+`, out)
+}
+
+func Test_newK8sNetworkPolicy(t *testing.T) {
+	policy := newK8sNetworkPolicy("shipa-app-api", "", map[string]string{k8sAppLabel: "api"}, nil, &shipa.NetworkPolicyConfig{PolicyMode: policyModeDenyAll})
+	assert.Equal(t, []string{"Egress"}, policy.Spec.PolicyTypes)
+	assert.Nil(t, policy.Spec.Ingress)
+	assert.Nil(t, policy.Spec.Egress)
+
+	policy = newK8sNetworkPolicy("shipa-app-api", "", nil, &shipa.NetworkPolicyConfig{
+		CustomRules: []*shipa.NetworkPolicyRule{{Enabled: false}, nil},
+	}, nil)
+	assert.Equal(t, []string{"Ingress"}, policy.Spec.PolicyTypes)
+	assert.Empty(t, policy.Spec.Ingress)
+}
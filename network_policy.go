@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+func reconcileNetworkPolicy(client *shipa.Client, desired *shipa.NetworkPolicy) error {
+	current, err := client.GetNetworkPolicy(context.TODO(), desired.App)
+	if err != nil {
+		// policy does not exist
+		current = &shipa.NetworkPolicy{App: desired.App}
+	}
+
+	diff := diffNetworkPolicy(current, desired)
+	if len(diff) == 0 {
+		fmt.Printf("network-policy: no changes for app %s\n", desired.App)
+		return nil
+	}
+
+	fmt.Printf("network-policy: changes for app %s:\n  %s\n", desired.App, strings.Join(diff, "\n  "))
+	err = client.CreateOrUpdateNetworkPolicy(context.TODO(), desired)
+	if err != nil {
+		return fmt.Errorf("failed to create shipa network-policy: %v", err)
+	}
+	return nil
+}
+
+func diffNetworkPolicy(current, desired *shipa.NetworkPolicy) []string {
+	var diff []string
+	if desired.Ingress != nil {
+		diff = append(diff, diffNetworkPolicyConfig("ingress", current.Ingress, desired.Ingress)...)
+	}
+	if desired.Egress != nil {
+		diff = append(diff, diffNetworkPolicyConfig("egress", current.Egress, desired.Egress)...)
+	}
+	return diff
+}
+
+func diffNetworkPolicyConfig(direction string, current, desired *shipa.NetworkPolicyConfig) []string {
+	if current == nil {
+		current = &shipa.NetworkPolicyConfig{}
+	}
+
+	// policy_mode and shipa_rules_enabled are compared only when declared, so defaults filled by shipa do not produce a diff
+	var diff []string
+	if desired.PolicyMode != "" && current.PolicyMode != desired.PolicyMode {
+		diff = append(diff, fmt.Sprintf("%s: policy_mode %q -> %q", direction, current.PolicyMode, desired.PolicyMode))
+	}
+
+	if desired.ShipaRulesEnabled != nil {
+		added, removed := diffStrings(current.ShipaRulesEnabled, desired.ShipaRulesEnabled)
+		for _, name := range added {
+			diff = append(diff, fmt.Sprintf("%s: + shipa rule %s enabled", direction, name))
+		}
+		for _, name := range removed {
+			diff = append(diff, fmt.Sprintf("%s: - shipa rule %s disabled", direction, name))
+		}
+	}
+
+	return append(diff, diffNetworkPolicyRules(direction, current.CustomRules, desired.CustomRules)...)
+}
+
+// diffNetworkPolicyRules matches rules by id, then by description, then by position
+func diffNetworkPolicyRules(direction string, current, desired []*shipa.NetworkPolicyRule) []string {
+	var diff []string
+	matched := make(map[int]bool)
+
+	for i, rule := range desired {
+		if rule == nil {
+			continue
+		}
+
+		j := findNetworkPolicyRule(current, matched, rule, i)
+		if j < 0 {
+			diff = append(diff, fmt.Sprintf("%s: + rule %s %s", direction, ruleName(rule, i), ruleJSON(rule, false)))
+			continue
+		}
+		matched[j] = true
+
+		before, after := ruleJSON(current[j], rule.ID == ""), ruleJSON(rule, rule.ID == "")
+		if before != after {
+			diff = append(diff, fmt.Sprintf("%s: ~ rule %s\n    - %s\n    + %s", direction, ruleName(rule, i), before, after))
+		}
+	}
+
+	for j, rule := range current {
+		if rule != nil && !matched[j] {
+			diff = append(diff, fmt.Sprintf("%s: - rule %s %s", direction, ruleName(rule, j), ruleJSON(rule, false)))
+		}
+	}
+
+	return diff
+}
+
+func findNetworkPolicyRule(rules []*shipa.NetworkPolicyRule, matched map[int]bool, rule *shipa.NetworkPolicyRule, position int) int {
+	for j, r := range rules {
+		if r != nil && !matched[j] && rule.ID != "" && r.ID == rule.ID {
+			return j
+		}
+	}
+
+	for j, r := range rules {
+		if r != nil && !matched[j] && rule.Description != "" && r.Description == rule.Description {
+			return j
+		}
+	}
+
+	if rule.ID == "" && rule.Description == "" && position < len(rules) && rules[position] != nil && !matched[position] {
+		return position
+	}
+
+	return -1
+}
+
+func ruleName(rule *shipa.NetworkPolicyRule, position int) string {
+	switch {
+	case rule.ID != "":
+		return rule.ID
+	case rule.Description != "":
+		return fmt.Sprintf("%q", rule.Description)
+	default:
+		return fmt.Sprintf("#%d", position+1)
+	}
+}
+
+// ruleJSON returns normalized rule, so ordering of ports, ip blocks and allowed apps does not produce a diff
+func ruleJSON(rule *shipa.NetworkPolicyRule, ignoreID bool) string {
+	normalized := *rule
+	if ignoreID {
+		normalized.ID = ""
+	}
+	normalized.AllowedApps = sortedStrings(rule.AllowedApps)
+	normalized.AllowedPools = sortedStrings(rule.AllowedPools)
+
+	normalized.Ports = nil
+	for _, port := range rule.Ports {
+		if port == nil {
+			continue
+		}
+		p := *port
+		p.Protocol = strings.ToUpper(p.Protocol)
+		if p.Protocol == "" {
+			p.Protocol = "TCP"
+		}
+		normalized.Ports = append(normalized.Ports, &p)
+	}
+	sort.Slice(normalized.Ports, func(i, j int) bool {
+		if normalized.Ports[i].Protocol != normalized.Ports[j].Protocol {
+			return normalized.Ports[i].Protocol < normalized.Ports[j].Protocol
+		}
+		return normalized.Ports[i].Port < normalized.Ports[j].Port
+	})
+
+	normalized.Peers = nil
+	for _, peer := range rule.Peers {
+		if peer == nil {
+			continue
+		}
+		p := *peer
+		p.IPBlock = sortedStrings(peer.IPBlock)
+		normalized.Peers = append(normalized.Peers, &p)
+	}
+
+	data, _ := json.Marshal(&normalized)
+	return string(data)
+}
+
+func sortedStrings(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := append([]string{}, values...)
+	sort.Strings(result)
+	return result
+}
+
+// diffStrings returns values added to and removed from the current list
+func diffStrings(current, desired []string) ([]string, []string) {
+	currentSet := make(map[string]bool)
+	for _, v := range current {
+		currentSet[v] = true
+	}
+
+	desiredSet := make(map[string]bool)
+	var added []string
+	for _, v := range desired {
+		desiredSet[v] = true
+		if !currentSet[v] {
+			added = append(added, v)
+		}
+	}
+
+	var removed []string
+	for _, v := range current {
+		if !desiredSet[v] {
+			removed = append(removed, v)
+		}
+	}
+
+	return added, removed
+}
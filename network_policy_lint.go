@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+// Network policy modes
+const (
+	policyModeAllowAll        = "allow-all"
+	policyModeDenyAll         = "deny-all"
+	policyModeCustomRulesOnly = "allow-custom-rules-only"
+)
+
+var validProtocols = map[string]bool{"TCP": true, "UDP": true, "SCTP": true}
+
+// lintNetworkPolicyConfig validates network policy config locally, references to apps and frameworks are checked with exists
+func lintNetworkPolicyConfig(field string, config *shipa.NetworkPolicyConfig, appExists, frameworkExists func(string) bool) []string {
+	if config == nil {
+		return nil
+	}
+
+	var msgs []string
+	switch config.PolicyMode {
+	case "", policyModeCustomRulesOnly:
+	case policyModeAllowAll, policyModeDenyAll:
+		if len(config.CustomRules) > 0 {
+			msgs = append(msgs, fmt.Sprintf("%s: custom_rules conflict with policy_mode %q, use %q to apply them",
+				field, config.PolicyMode, policyModeCustomRulesOnly))
+		}
+	default:
+		msgs = append(msgs, fmt.Sprintf("%s: unknown policy_mode %q, expected one of %q, %q, %q",
+			field, config.PolicyMode, policyModeAllowAll, policyModeDenyAll, policyModeCustomRulesOnly))
+	}
+
+	for i, rule := range config.CustomRules {
+		if rule != nil {
+			msgs = append(msgs, lintNetworkPolicyRule(fmt.Sprintf("%s.custom_rules[%d]", field, i), rule, appExists, frameworkExists)...)
+		}
+	}
+
+	return msgs
+}
+
+func lintNetworkPolicyRule(field string, rule *shipa.NetworkPolicyRule, appExists, frameworkExists func(string) bool) []string {
+	var msgs []string
+	for i, port := range rule.Ports {
+		if port == nil {
+			continue
+		}
+
+		if port.Protocol != "" && !validProtocols[strings.ToUpper(port.Protocol)] {
+			msgs = append(msgs, fmt.Sprintf("%s.ports[%d]: unsupported protocol %q, expected TCP, UDP or SCTP", field, i, port.Protocol))
+		}
+		// port 0 means the port is not set, the rule then applies to all ports of the protocol
+		if port.Port < 0 || port.Port > 65535 {
+			msgs = append(msgs, fmt.Sprintf("%s.ports[%d]: port %d is out of range 1-65535, omit it to allow all ports", field, i, port.Port))
+		}
+	}
+
+	for i, peer := range rule.Peers {
+		if peer == nil {
+			continue
+		}
+
+		peerField := fmt.Sprintf("%s.peers[%d]", field, i)
+		for _, cidr := range peer.IPBlock {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s.ipBlock: invalid CIDR %q", peerField, cidr))
+			}
+		}
+
+		msgs = append(msgs, lintPeerSelector(peerField+".podSelector", peer.PodSelector)...)
+		msgs = append(msgs, lintPeerSelector(peerField+".namespaceSelector", peer.NamespaceSelector)...)
+	}
+
+	for _, app := range rule.AllowedApps {
+		if !appExists(app) {
+			msgs = append(msgs, fmt.Sprintf("%s.allowed_apps: app %q does not exist", field, app))
+		}
+	}
+
+	for _, framework := range rule.AllowedPools {
+		if !frameworkExists(framework) {
+			msgs = append(msgs, fmt.Sprintf("%s.allowed_frameworks: framework %q does not exist", field, framework))
+		}
+	}
+
+	return msgs
+}
+
+func lintPeerSelector(field string, selector *shipa.NetworkPeerSelector) []string {
+	if selector == nil {
+		return nil
+	}
+
+	var msgs []string
+	for i, expr := range selector.MatchExpressions {
+		if expr == nil {
+			continue
+		}
+
+		exprField := fmt.Sprintf("%s.matchExpressions[%d]", field, i)
+		if expr.Key == "" {
+			msgs = append(msgs, fmt.Sprintf("%s: key is required", exprField))
+		}
+
+		switch expr.Operator {
+		case "In", "NotIn":
+			if len(expr.Values) == 0 {
+				msgs = append(msgs, fmt.Sprintf("%s: operator %s requires values", exprField, expr.Operator))
+			}
+		case "Exists", "DoesNotExist":
+			if len(expr.Values) > 0 {
+				msgs = append(msgs, fmt.Sprintf("%s: operator %s does not accept values", exprField, expr.Operator))
+			}
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s: unknown operator %q, expected In, NotIn, Exists or DoesNotExist", exprField, expr.Operator))
+		}
+	}
+
+	return msgs
+}
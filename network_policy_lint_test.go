@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_lintNetworkPolicyConfig(t *testing.T) {
+	exists := func(names ...string) func(string) bool {
+		return func(name string) bool {
+			for _, n := range names {
+				if n == name {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	appExists, frameworkExists := exists("frontend"), exists("dev")
+
+	valid := &shipa.NetworkPolicyConfig{
+		PolicyMode: policyModeCustomRulesOnly,
+		CustomRules: []*shipa.NetworkPolicyRule{
+			{
+				Enabled:      true,
+				Ports:        []*shipa.NetworkPort{{Protocol: "tcp", Port: 443}, {Protocol: "UDP"}},
+				Peers:        []*shipa.NetworkPeer{{IPBlock: []string{"10.0.0.0/8"}}},
+				AllowedApps:  []string{"frontend"},
+				AllowedPools: []string{"dev"},
+			},
+		},
+	}
+	assert.Empty(t, lintNetworkPolicyConfig("ingress", valid, appExists, frameworkExists))
+	assert.Empty(t, lintNetworkPolicyConfig("ingress", nil, appExists, frameworkExists))
+
+	assert.Equal(t, []string{
+		`ingress: unknown policy_mode "allow-some", expected one of "allow-all", "deny-all", "allow-custom-rules-only"`,
+	}, lintNetworkPolicyConfig("ingress", &shipa.NetworkPolicyConfig{PolicyMode: "allow-some"}, appExists, frameworkExists))
+
+	invalid := &shipa.NetworkPolicyConfig{
+		PolicyMode: policyModeDenyAll,
+		CustomRules: []*shipa.NetworkPolicyRule{
+			{
+				Ports:        []*shipa.NetworkPort{{Protocol: "ICMP", Port: 80}, {Port: -1}, {Port: 70000}},
+				Peers:        []*shipa.NetworkPeer{{IPBlock: []string{"10.0.0.0"}}},
+				AllowedApps:  []string{"backend"},
+				AllowedPools: []string{"prod"},
+			},
+		},
+	}
+	assert.Equal(t, []string{
+		`ingress: custom_rules conflict with policy_mode "deny-all", use "allow-custom-rules-only" to apply them`,
+		`ingress.custom_rules[0].ports[0]: unsupported protocol "ICMP", expected TCP, UDP or SCTP`,
+		"ingress.custom_rules[0].ports[1]: port -1 is out of range 1-65535, omit it to allow all ports",
+		"ingress.custom_rules[0].ports[2]: port 70000 is out of range 1-65535, omit it to allow all ports",
+		`ingress.custom_rules[0].peers[0].ipBlock: invalid CIDR "10.0.0.0"`,
+		`ingress.custom_rules[0].allowed_apps: app "backend" does not exist`,
+		`ingress.custom_rules[0].allowed_frameworks: framework "prod" does not exist`,
+	}, lintNetworkPolicyConfig("ingress", invalid, appExists, frameworkExists))
+}
+
+func Test_lintPeerSelector(t *testing.T) {
+	assert.Empty(t, lintPeerSelector("podSelector", nil))
+
+	selector := &shipa.NetworkPeerSelector{
+		MatchExpressions: []*shipa.SelectorExpression{
+			{Key: "app", Operator: "In", Values: []string{"web"}},
+			{Key: "tier", Operator: "Exists"},
+			{Key: "app", Operator: "NotIn"},
+			{Key: "tier", Operator: "DoesNotExist", Values: []string{"db"}},
+			{Operator: "Equals", Values: []string{"web"}},
+		},
+	}
+	assert.Equal(t, []string{
+		"podSelector.matchExpressions[2]: operator NotIn requires values",
+		"podSelector.matchExpressions[3]: operator DoesNotExist does not accept values",
+		"podSelector.matchExpressions[4]: key is required",
+		`podSelector.matchExpressions[4]: unknown operator "Equals", expected In, NotIn, Exists or DoesNotExist`,
+	}, lintPeerSelector("podSelector", selector))
+}
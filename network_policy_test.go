@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_diffNetworkPolicy(t *testing.T) {
+	web := func() *shipa.NetworkPolicyRule {
+		return &shipa.NetworkPolicyRule{
+			Enabled:     true,
+			Description: "web",
+			Ports:       []*shipa.NetworkPort{{Protocol: "TCP", Port: 80}, {Protocol: "TCP", Port: 443}},
+			AllowedApps: []string{"frontend", "admin"},
+		}
+	}
+	metrics := func() *shipa.NetworkPolicyRule {
+		return &shipa.NetworkPolicyRule{
+			Enabled: true,
+			Ports:   []*shipa.NetworkPort{{Port: 9090}},
+		}
+	}
+	withID := func(rule *shipa.NetworkPolicyRule, id string) *shipa.NetworkPolicyRule {
+		rule.ID = id
+		return rule
+	}
+	policy := func(rules ...*shipa.NetworkPolicyRule) *shipa.NetworkPolicy {
+		return &shipa.NetworkPolicy{
+			App: "api",
+			Ingress: &shipa.NetworkPolicyConfig{
+				PolicyMode:  "allow-custom-rules-only",
+				CustomRules: rules,
+			},
+		}
+	}
+
+	live := policy(withID(web(), "rule-1"), withID(metrics(), "rule-2"))
+	live.Ingress.ShipaRulesEnabled = []string{"allow-ingress-controller"}
+
+	undeclared := policy(web(), metrics())
+	undeclared.Ingress.PolicyMode = ""
+
+	reordered := web()
+	reordered.Ports = []*shipa.NetworkPort{{Protocol: "tcp", Port: 443}, {Port: 80}}
+	reordered.AllowedApps = []string{"admin", "frontend"}
+
+	changed := metrics()
+	changed.Ports[0].Port = 9100
+
+	tests := []struct {
+		name    string
+		desired *shipa.NetworkPolicy
+		want    []string
+	}{
+		{
+			name:    "unchanged, ids assigned by shipa",
+			desired: policy(web(), metrics()),
+		},
+		{
+			name:    "policy mode and shipa rules filled by shipa",
+			desired: undeclared,
+		},
+		{
+			name:    "reordered ports and apps",
+			desired: policy(reordered, metrics()),
+		},
+		{
+			name:    "matched by id",
+			desired: policy(withID(metrics(), "rule-2"), withID(web(), "rule-1")),
+		},
+		{
+			name:    "changed rule",
+			desired: policy(web(), changed),
+			want: []string{
+				"ingress: ~ rule #2\n" +
+					`    - {"enabled":true,"ports":[{"protocol":"TCP","port":9090}]}` + "\n" +
+					`    + {"enabled":true,"ports":[{"protocol":"TCP","port":9100}]}`,
+			},
+		},
+		{
+			name:    "added rule",
+			desired: policy(web(), metrics(), &shipa.NetworkPolicyRule{Enabled: true, Description: "dns"}),
+			want:    []string{`ingress: + rule "dns" {"enabled":true,"description":"dns"}`},
+		},
+		{
+			name:    "removed rule",
+			desired: policy(web()),
+			want:    []string{`ingress: - rule rule-2 {"id":"rule-2","enabled":true,"ports":[{"protocol":"TCP","port":9090}]}`},
+		},
+		{
+			name: "policy mode and shipa rules",
+			desired: &shipa.NetworkPolicy{
+				App: "api",
+				Ingress: &shipa.NetworkPolicyConfig{
+					PolicyMode:        "allow-all",
+					ShipaRulesEnabled: []string{"allow-dns"},
+					CustomRules:       []*shipa.NetworkPolicyRule{web(), metrics()},
+				},
+			},
+			want: []string{
+				`ingress: policy_mode "allow-custom-rules-only" -> "allow-all"`,
+				"ingress: + shipa rule allow-dns enabled",
+				"ingress: - shipa rule allow-ingress-controller disabled",
+			},
+		},
+		{
+			name: "shipa rules cleared",
+			desired: &shipa.NetworkPolicy{
+				App: "api",
+				Ingress: &shipa.NetworkPolicyConfig{
+					ShipaRulesEnabled: []string{},
+					CustomRules:       []*shipa.NetworkPolicyRule{web(), metrics()},
+				},
+			},
+			want: []string{"ingress: - shipa rule allow-ingress-controller disabled"},
+		},
+		{
+			name:    "undeclared direction is ignored",
+			desired: &shipa.NetworkPolicy{App: "api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffNetworkPolicy(live, tt.desired))
+		})
+	}
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+// preflight runs checks before any mutation, so a failing check does not leave half-applied state
+type preflight struct {
+	client  *shipa.Client
+	action  *ShipaAction
+	options *actionOptions
+
+	apps       map[string]*shipa.App
+	frameworks map[string]*shipa.PoolConfig
+	teams      map[string]bool
+	plans      map[string]bool
+
+	// lookups failed for other reasons than absence, e.g. auth or network errors
+	failed       map[string]bool
+	lookupErrors []string
+}
+
+func newPreflight(client *shipa.Client, action *ShipaAction, options *actionOptions) *preflight {
+	return &preflight{
+		client:     client,
+		action:     action,
+		options:    options,
+		apps:       make(map[string]*shipa.App),
+		frameworks: make(map[string]*shipa.PoolConfig),
+		teams:      make(map[string]bool),
+		plans:      make(map[string]bool),
+		failed:     make(map[string]bool),
+	}
+}
+
+// lookupFailed records errors other than not found, so they are not reported as missing objects
+func (p *preflight) lookupFailed(kind, name string, err error) bool {
+	if err == nil || shipa.IsNotFound(err) {
+		return false
+	}
+
+	key := kind + "/" + name
+	if !p.failed[key] {
+		p.failed[key] = true
+		p.lookupErrors = append(p.lookupErrors, fmt.Sprintf("failed to get shipa %s %s: %v", kind, name, err))
+	}
+	return true
+}
+
+func (p *preflight) run() error {
+	if deploy := p.action.AppDeploy; deploy != nil {
+		deploy.SetHookOwner(p.appFramework(deploy.App), p.appTeam(deploy.App))
+	}
+
+	checks := []func() error{
+		p.checkReferences,
+		p.checkCluster,
+		p.checkAppEnv,
+		p.checkCnames,
+		p.checkNetworkPolicies,
+		p.checkGuardrails,
+		p.checkJob,
+		p.checkUsers,
+		p.checkDeployImage,
+	}
+
+	var msgs []string
+	for _, check := range checks {
+		if err := check(); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	msgs = append(p.lookupErrors, msgs...)
+
+	if len(msgs) > 0 {
+		return fmt.Errorf("preflight failed:\n  %s", strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
+
+// app returns live app or nil when it does not exist yet
+func (p *preflight) app(name string) *shipa.App {
+	if app, ok := p.apps[name]; ok {
+		return app
+	}
+
+	app, err := p.client.GetApp(context.TODO(), name)
+	if err != nil {
+		p.lookupFailed("app", name, err)
+		app = nil
+	}
+	p.apps[name] = app
+	return app
+}
+
+// appFramework returns framework name of the app, falling back to the app declared in the action file
+func (p *preflight) appFramework(appName string) string {
+	if app := p.app(appName); app != nil && app.Pool != "" {
+		return app.Pool
+	}
+
+	if p.action.App != nil && p.action.App.Name == appName && p.action.App.Pool != "" {
+		return p.action.App.Pool
+	}
+
+	return ""
+}
+
+// appTeam returns team owner of the app, falling back to the app declared in the action file
+func (p *preflight) appTeam(appName string) string {
+	if app := p.app(appName); app != nil && app.TeamOwner != "" {
+		return app.TeamOwner
+	}
+
+	if p.action.App != nil && p.action.App.Name == appName {
+		return p.action.App.TeamOwner
+	}
+
+	return ""
+}
+
+// framework returns live framework config, or the one declared in the action file when it does not exist yet
+func (p *preflight) framework(name string) *shipa.PoolConfig {
+	if framework := p.liveFramework(name); framework != nil {
+		return framework
+	}
+
+	if p.action.Framework != nil && p.action.Framework.Name == name {
+		return p.action.Framework
+	}
+	return nil
+}
+
+// liveFramework returns framework config or nil when it does not exist yet
+func (p *preflight) liveFramework(name string) *shipa.PoolConfig {
+	if framework, ok := p.frameworks[name]; ok {
+		return framework
+	}
+
+	framework, err := p.client.GetPoolConfig(context.TODO(), name)
+	if err != nil {
+		p.lookupFailed("framework", name, err)
+		framework = nil
+	}
+	p.frameworks[name] = framework
+	return framework
+}
+
+func (p *preflight) teamExists(name string) bool {
+	if exists, ok := p.teams[name]; ok {
+		return exists
+	}
+
+	for _, team := range p.action.Teams {
+		if team != nil && team.Name == name {
+			p.teams[name] = true
+			return true
+		}
+	}
+
+	// teams failed to load are reported by lookupFailed, not as missing
+	_, err := p.client.GetTeam(context.TODO(), name)
+	p.teams[name] = err == nil || p.lookupFailed("team", name, err)
+	return p.teams[name]
+}
+
+func (p *preflight) planExists(name string) bool {
+	if exists, ok := p.plans[name]; ok {
+		return exists
+	}
+
+	_, err := p.client.GetPlan(context.TODO(), name)
+	p.plans[name] = err == nil || p.lookupFailed("plan", name, err)
+	return p.plans[name]
+}
+
+// checkReferences makes sure objects referenced in the action file exist or are declared in the same file
+func (p *preflight) checkReferences() error {
+	var msgs []string
+	checkFramework := func(field, name string) {
+		if name != "" && p.framework(name) == nil && !p.failed["framework/"+name] {
+			msgs = append(msgs, fmt.Sprintf("%s: framework %q does not exist and is not declared in the action file", field, name))
+		}
+	}
+	checkTeam := func(field, name string) {
+		if name != "" && !p.teamExists(name) {
+			msgs = append(msgs, fmt.Sprintf("%s: team %q does not exist", field, name))
+		}
+	}
+	checkPlan := func(field, name string) {
+		if name != "" && !p.planExists(name) {
+			msgs = append(msgs, fmt.Sprintf("%s: plan %q does not exist", field, name))
+		}
+	}
+
+	if framework := p.action.Framework; framework != nil && framework.Resources != nil && framework.Resources.General != nil {
+		if plan := framework.Resources.General.Plan; plan != nil {
+			checkPlan("framework.plan", plan.Name)
+		}
+	}
+
+	if cluster := p.action.Cluster; cluster != nil && cluster.Resources != nil && cluster.Resources.Frameworks != nil {
+		for _, name := range cluster.Resources.Frameworks.Name {
+			checkFramework("cluster.resources.frameworks", name)
+		}
+	}
+
+	if app := p.action.App; app != nil {
+		checkFramework("app.framework", app.Pool)
+		checkTeam("app.teamOwner", app.TeamOwner)
+		checkPlan("app.plan", app.Plan)
+	}
+
+	if deploy := p.action.AppDeploy; deploy != nil && deploy.AppConfig != nil {
+		checkFramework("app-deploy.appConfig.framework", deploy.AppConfig.Framework)
+		checkTeam("app-deploy.appConfig.team", deploy.AppConfig.Team)
+		checkPlan("app-deploy.appConfig.plan", deploy.AppConfig.Plan)
+	}
+
+	if job := p.action.Job; job != nil {
+		checkFramework("job.framework", job.Framework)
+		checkTeam("job.team", job.Team)
+	}
+
+	if deploy := p.action.AppDeploy; deploy != nil {
+		if hook := deploy.PreDeploy; hook != nil {
+			checkFramework("app-deploy.preDeploy.framework", hook.Framework)
+			checkTeam("app-deploy.preDeploy.team", hook.Team)
+		}
+		if hook := deploy.PostDeploy; hook != nil {
+			checkFramework("app-deploy.postDeploy.framework", hook.Framework)
+			checkTeam("app-deploy.postDeploy.team", hook.Team)
+		}
+	}
+
+	declaredRoles := make(map[string]bool)
+	for _, role := range p.action.Roles {
+		if role != nil {
+			declaredRoles[role.Name] = true
+		}
+	}
+	for _, binding := range p.action.RoleBindings {
+		if binding == nil || declaredRoles[binding.Role] {
+			continue
+		}
+		if _, err := p.client.GetRole(context.TODO(), binding.Role); err != nil && !p.lookupFailed("role", binding.Role, err) {
+			msgs = append(msgs, fmt.Sprintf("role-bindings: role %q does not exist and is not declared in the action file", binding.Role))
+		}
+	}
+
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
+
+func (p *preflight) appFrameworkConfig(appName string) (string, *shipa.PoolGeneral) {
+	name := p.appFramework(appName)
+	if name == "" {
+		return "", nil
+	}
+
+	framework := p.framework(name)
+	if framework == nil || framework.Resources == nil {
+		return name, nil
+	}
+	return name, framework.Resources.General
+}
+
+func (p *preflight) checkNetworkPolicies() error {
+	appExists := func(name string) bool {
+		return p.app(name) != nil || (p.action.App != nil && p.action.App.Name == name)
+	}
+	frameworkExists := func(name string) bool {
+		return p.framework(name) != nil
+	}
+
+	var msgs []string
+	if policy := p.action.NetworkPolicy; policy != nil {
+		msgs = append(msgs, lintNetworkPolicyConfig("network-policy.ingress", policy.Ingress, appExists, frameworkExists)...)
+		msgs = append(msgs, lintNetworkPolicyConfig("network-policy.egress", policy.Egress, appExists, frameworkExists)...)
+	}
+
+	if framework := p.action.Framework; framework != nil && framework.Resources != nil && framework.Resources.General != nil {
+		if policy := framework.Resources.General.NetworkPolicy; policy != nil {
+			msgs = append(msgs, lintNetworkPolicyConfig("framework.networkPolicy.ingress", policy.Ingress, appExists, frameworkExists)...)
+			msgs = append(msgs, lintNetworkPolicyConfig("framework.networkPolicy.egress", policy.Egress, appExists, frameworkExists)...)
+		}
+	}
+
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
+
+func (p *preflight) checkJob() error {
+	var msgs []string
+	if p.action.Job != nil {
+		msgs = append(msgs, validateJob("job", p.action.Job)...)
+	}
+
+	if deploy := p.action.AppDeploy; deploy != nil {
+		if deploy.PreDeploy != nil {
+			if len(deploy.PreDeploy.Containers) == 0 {
+				msgs = append(msgs, "app-deploy.preDeploy.containers: at least one container is required")
+			}
+			if deploy.PreDeploy.Framework == "" {
+				msgs = append(msgs, fmt.Sprintf("app-deploy.preDeploy.framework: required, app %s does not exist and has no framework in appConfig", deploy.App))
+			}
+			msgs = append(msgs, validateJob("app-deploy.preDeploy", deploy.PreDeploy)...)
+		}
+		if deploy.PostDeploy != nil {
+			if len(deploy.PostDeploy.Containers) == 0 {
+				msgs = append(msgs, "app-deploy.postDeploy.containers: at least one container is required")
+			}
+			if deploy.PostDeploy.Framework == "" {
+				msgs = append(msgs, fmt.Sprintf("app-deploy.postDeploy.framework: required, app %s does not exist and has no framework in appConfig", deploy.App))
+			}
+			msgs = append(msgs, validateJob("app-deploy.postDeploy", deploy.PostDeploy)...)
+		}
+	}
+
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
+
+func (p *preflight) checkCnames() error {
+	input := p.action.AppCname
+	if input == nil {
+		return nil
+	}
+
+	frameworkName, general := p.appFrameworkConfig(input.App)
+	if general == nil || general.DomainPolicy == nil || len(general.DomainPolicy.AllowedCnames) == 0 {
+		return nil
+	}
+
+	var msgs []string
+	for _, cname := range input.ToShipaCnames() {
+		if !cnameAllowed(cname.Cname, general.DomainPolicy.AllowedCnames) {
+			msgs = append(msgs, fmt.Sprintf("cname %q is not allowed by framework %q, allowed patterns: %v",
+				cname.Cname, frameworkName, general.DomainPolicy.AllowedCnames))
+		}
+	}
+
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
+
+func cnameAllowed(cname string, patterns []string) bool {
+	cname = strings.ToLower(cname)
+	for _, pattern := range patterns {
+		ok, err := path.Match(strings.ToLower(pattern), cname)
+		if err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *preflight) checkDeployImage() error {
+	deploy := p.action.AppDeploy
+	if deploy == nil || deploy.Image == "" {
+		return nil
+	}
+
+	frameworkName := ""
+	if deploy.AppConfig != nil {
+		frameworkName = deploy.AppConfig.Framework
+	}
+	if frameworkName == "" {
+		frameworkName = p.appFramework(deploy.App)
+	}
+	if frameworkName == "" {
+		return nil
+	}
+
+	framework := p.framework(frameworkName)
+	if framework == nil || framework.Resources == nil || framework.Resources.General == nil {
+		return nil
+	}
+
+	policy := framework.Resources.General.ContainerPolicy
+	if policy == nil || len(policy.AllowedHosts) == 0 {
+		return nil
+	}
+
+	host := imageRegistryHost(deploy.Image)
+	if !imageAllowed(deploy.Image, policy.AllowedHosts) {
+		return fmt.Errorf("image %q is pulled from %q, which is not allowed by framework %q, allowed hosts: %v; "+
+			"push the image to one of the allowed registries or update the framework containerPolicy",
+			deploy.Image, host, frameworkName, policy.AllowedHosts)
+	}
+	return nil
+}
+
+const defaultRegistryHost = "docker.io"
+
+// imageRegistryHost resolves registry host of an image reference
+func imageRegistryHost(image string) string {
+	host, _ := splitImage(image)
+	return host
+}
+
+// splitImage splits image reference into registry host and repository the same way docker does:
+// the first path component is a host only if it looks like one, otherwise the image comes from Docker Hub
+func splitImage(image string) (string, string) {
+	idx := strings.Index(image, "/")
+	if idx < 0 {
+		return defaultRegistryHost, image
+	}
+
+	first := image[:idx]
+	if first != "localhost" && !strings.ContainsAny(first, ".:") {
+		return defaultRegistryHost, image
+	}
+	return normalizeRegistryHost(first), image[idx+1:]
+}
+
+func normalizeRegistryHost(host string) string {
+	host = strings.ToLower(host)
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return defaultRegistryHost
+	}
+	return host
+}
+
+// imageAllowed checks image against allowed hosts, entries may contain a port, a path prefix or wildcards
+func imageAllowed(image string, allowedHosts []string) bool {
+	host, repository := splitImage(image)
+	hostname := host
+	if idx := strings.LastIndex(host, ":"); idx >= 0 {
+		hostname = host[:idx]
+	}
+	fullName := host + "/" + repository
+
+	for _, allowed := range allowedHosts {
+		allowed = strings.TrimPrefix(strings.TrimPrefix(allowed, "https://"), "http://")
+		allowed = strings.TrimSuffix(allowed, "/")
+
+		if idx := strings.Index(allowed, "/"); idx >= 0 {
+			prefix := normalizeRegistryHost(allowed[:idx]) + allowed[idx:] + "/"
+			if strings.HasPrefix(fullName, prefix) {
+				return true
+			}
+			continue
+		}
+
+		allowed = normalizeRegistryHost(allowed)
+		for _, candidate := range []string{host, hostname} {
+			if ok, err := path.Match(allowed, candidate); err == nil && ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkCluster reads credential sources and checks credentials, so a broken cluster block fails before access is reconciled
+func (p *preflight) checkCluster() error {
+	if p.action.Cluster == nil {
+		return nil
+	}
+
+	_, err := resolveCluster(p.action.Cluster)
+	return err
+}
+
+// checkAppEnv reads env files and decrypts values, so a missing file or a wrong key fails before anything is changed
+func (p *preflight) checkAppEnv() error {
+	if p.action.AppEnv == nil {
+		return nil
+	}
+
+	_, err := resolveAppEnvs(p.action.AppEnv)
+	return err
+}
+
+func (p *preflight) checkUsers() error {
+	var msgs []string
+	declared := make(map[string]bool)
+	if users := p.action.Users; users != nil {
+		for i, member := range users.Members {
+			if member == nil {
+				continue
+			}
+			if member.Email == "" {
+				msgs = append(msgs, fmt.Sprintf("users.members[%d].email: required", i))
+				continue
+			}
+			declared[member.Email] = true
+
+			if _, err := member.ToShipaUser(); err != nil {
+				msgs = append(msgs, fmt.Sprintf("users.members[%d]: %v", i, err))
+			}
+		}
+	}
+
+	if len(p.action.RoleBindings) > 0 {
+		users, err := p.client.ListUsers(context.TODO())
+		if err != nil {
+			return fmt.Errorf("failed to list shipa users: %v", err)
+		}
+		for _, user := range users {
+			declared[user.Email] = true
+		}
+
+		for _, binding := range p.action.RoleBindings {
+			if binding == nil {
+				continue
+			}
+			for _, email := range binding.Users {
+				if !declared[email] {
+					msgs = append(msgs, fmt.Sprintf("role-bindings: user %q of role %q does not exist and is not declared in users", email, binding.Role))
+				}
+			}
+		}
+	}
+
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/brunoa19/shipa-github-actions/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_imageAllowed(t *testing.T) {
+	allowed := []string{"docker.io", "gcr.io/my-project", "*.azurecr.io", "localhost:5000"}
+
+	assert.True(t, imageAllowed("nginx:latest", allowed))
+	assert.True(t, imageAllowed("index.docker.io/library/nginx", allowed))
+	assert.True(t, imageAllowed("gcr.io/my-project/api:v1", allowed))
+	assert.True(t, imageAllowed("acme.azurecr.io/api@sha256:abc", allowed))
+	assert.True(t, imageAllowed("localhost:5000/api", allowed))
+
+	assert.False(t, imageAllowed("gcr.io/other-project/api:v1", allowed))
+	assert.False(t, imageAllowed("quay.io/org/api", allowed))
+	assert.False(t, imageAllowed("localhost/api", allowed))
+}
+
+func Test_cnameAllowed(t *testing.T) {
+	patterns := []string{"*.example.com", "api.acme.bar"}
+
+	assert.True(t, cnameAllowed("app.example.com", patterns))
+	assert.True(t, cnameAllowed("API.acme.bar", patterns))
+	assert.False(t, cnameAllowed("example.com", patterns))
+	assert.False(t, cnameAllowed("app.acme.bar", patterns))
+}
+
+func Test_checkReferences(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/frameworks-config/dev":
+			fmt.Fprint(w, `{"name": "dev"}`)
+		case "/teams/backend":
+			fmt.Fprint(w, `{"name": "backend"}`)
+		case "/plans":
+			fmt.Fprint(w, `[{"name": "small"}]`)
+		case "/roles/deployer":
+			fmt.Fprint(w, `{"name": "deployer"}`)
+		case "/frameworks-config/locked", "/teams/locked":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "unauthorized")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "not found")
+		}
+	}))
+	defer srv.Close()
+
+	client := &shipa.Client{HostURL: srv.URL, HTTPClient: srv.Client()}
+	action := &ShipaAction{
+		App:          &shipa.CreateAppRequest{Name: "api", Pool: "dev", TeamOwner: "backend", Plan: "small"},
+		Framework:    &shipa.PoolConfig{Name: "new"},
+		Teams:        []*shipa.Team{{Name: "platform"}},
+		Roles:        []*types.Role{{Name: "viewer"}},
+		RoleBindings: []*types.RoleBinding{{Role: "deployer"}, {Role: "viewer"}},
+		Job: &types.Job{JobCreateRequest: shipa.JobCreateRequest{
+			Framework: "new",
+			Team:      "platform",
+		}},
+	}
+	assert.NoError(t, newPreflight(client, action, nil).checkReferences())
+
+	action.App = &shipa.CreateAppRequest{Name: "api", Pool: "missing", TeamOwner: "ghost", Plan: "huge"}
+	action.RoleBindings = append(action.RoleBindings, &types.RoleBinding{Role: "admin"})
+	assert.EqualError(t, newPreflight(client, action, nil).checkReferences(), `app.framework: framework "missing" does not exist and is not declared in the action file
+  app.teamOwner: team "ghost" does not exist
+  app.plan: plan "huge" does not exist
+  role-bindings: role "admin" does not exist and is not declared in the action file`)
+
+	// lookups failed for other reasons are not reported as missing objects
+	action = &ShipaAction{App: &shipa.CreateAppRequest{Name: "api", Pool: "locked", TeamOwner: "locked"}}
+	p := newPreflight(client, action, nil)
+	assert.NoError(t, p.checkReferences())
+	assert.Equal(t, []string{
+		"failed to get shipa framework locked: status: 401, body: unauthorized",
+		"failed to get shipa team locked: status: 401, body: unauthorized",
+	}, p.lookupErrors)
+}
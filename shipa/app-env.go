@@ -6,6 +6,17 @@ import "context"
 type AppEnv struct {
 	Name  string `json:"name" yaml:"name"`
 	Value string `json:"value" yaml:"value"`
+
+	// set by shipa when envs are listed, values of private envs are masked
+	Public *bool `json:"public,omitempty" yaml:"-"`
+}
+
+// privateEnvMask - value returned by shipa instead of the value of a private env
+const privateEnvMask = "*** (private variable)"
+
+// Masked returns true when the value is hidden by shipa, i.e. the env is private
+func (e *AppEnv) Masked() bool {
+	return (e.Public != nil && !*e.Public) || e.Value == privateEnvMask
 }
 
 // CreateAppEnv - request to create AppEnv
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -156,7 +157,7 @@ func (c *Client) newURLEncodedRequest(ctx context.Context, method string, params
 
 	if c.debug {
 		log.Printf("\n> %s: %s\n", method, URL)
-		log.Printf(">>> Payload: %+v\n", params)
+		log.Printf(">>> Payload: %+v\n", redactParams(params))
 	}
 
 	data := url.Values{}
@@ -182,7 +183,7 @@ func (c *Client) newRequest(ctx context.Context, method string, payload interfac
 		}
 
 		if c.debug {
-			log.Printf(">>> Payload: %s\n", string(data))
+			log.Printf(">>> Payload: %s\n", redactPayload(data))
 		}
 
 		body = bytes.NewBuffer(data)
@@ -216,7 +217,7 @@ func (c *Client) newRequestWithParams(ctx context.Context, method string, payloa
 		}
 
 		if c.debug {
-			log.Printf(">>> Payload: %s\n", string(data))
+			log.Printf(">>> Payload: %s\n", redactPayload(data))
 		}
 
 		body = bytes.NewBuffer(data)
@@ -231,7 +232,7 @@ func (c *Client) newRequestWithParamsList(ctx context.Context, method string, pa
 
 	paramValues := make([]string, 0)
 	for _, p := range params {
-		paramValues = append(paramValues, fmt.Sprintf("%s=%v", p.Key, p.Val))
+		paramValues = append(paramValues, fmt.Sprintf("%s=%s", url.QueryEscape(p.Key), url.QueryEscape(fmt.Sprint(p.Val))))
 	}
 	paramsStr := strings.Join(paramValues, "&")
 
@@ -250,7 +251,7 @@ func (c *Client) newRequestWithParamsList(ctx context.Context, method string, pa
 		}
 
 		if c.debug {
-			log.Printf(">>> Payload: %s\n", string(data))
+			log.Printf(">>> Payload: %s\n", redactPayload(data))
 		}
 
 		body = bytes.NewBuffer(data)
@@ -440,9 +441,59 @@ func (c *Client) deleteWithPayload(ctx context.Context, payload interface{}, par
 	return nil
 }
 
+// sensitiveFields are masked in debug logs, "value" holds env values of apps and jobs, which may be decrypted secrets
+var sensitiveFields = regexp.MustCompile(`(?i)("(?:password|token|clientKey|secret|value)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+func redactPayload(data []byte) string {
+	return sensitiveFields.ReplaceAllString(string(data), `$1"***"`)
+}
+
+func redactParams(params map[string]string) map[string]string {
+	result := make(map[string]string, len(params))
+	for key, val := range params {
+		if sensitiveFields.MatchString(fmt.Sprintf("%q:%q", key, val)) {
+			val = "***"
+		}
+		result[key] = val
+	}
+	return result
+}
+
+// StatusError - error returned when shipa responds with unexpected status
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status: %d, body: %s", e.StatusCode, e.Body)
+}
+
 // ErrStatus - returns error with status and message
 func ErrStatus(statusCode int, body []byte) error {
-	return fmt.Errorf("status: %d, body: %s", statusCode, body)
+	return &StatusError{StatusCode: statusCode, Body: body}
+}
+
+// IsNotFound - checks if error means the requested object does not exist,
+// shipa responds with 404 or with a client error mentioning "not found"
+func IsNotFound(err error) bool {
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrPlanNotFound) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+
+	switch code := statusErr.StatusCode; {
+	case code == http.StatusNotFound:
+		return true
+	case code == http.StatusUnauthorized, code == http.StatusForbidden, code >= http.StatusInternalServerError:
+		return false
+	default:
+		return strings.Contains(strings.ToLower(string(statusErr.Body)), "not found")
+	}
 }
 
 func (c *Client) testAuthentication() error {
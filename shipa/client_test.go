@@ -0,0 +1,36 @@
+package shipa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_redactPayload(t *testing.T) {
+	data, err := json.Marshal(&CreateAppEnv{
+		App:     "api",
+		Envs:    []*AppEnv{{Name: "DB_PASSWORD", Value: `s3cr"t`}, {Name: "PORT", Value: "8080"}},
+		Private: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`{"envs":[{"name":"DB_PASSWORD","value":"***"},{"name":"PORT","value":"***"}],"norestart":false,"private":true}`,
+		redactPayload(data))
+
+	assert.Equal(t, `{"email":"dev@acme.io","password":"***"}`, redactPayload([]byte(`{"email":"dev@acme.io","password":"p/ss"}`)))
+}
+
+func Test_IsNotFound(t *testing.T) {
+	assert.True(t, IsNotFound(ErrStatus(404, []byte("app not found"))))
+	assert.True(t, IsNotFound(ErrStatus(400, []byte("Cluster not found"))))
+	assert.True(t, IsNotFound(fmt.Errorf("failed: %w", ErrUserNotFound)))
+	assert.True(t, IsNotFound(ErrPlanNotFound))
+
+	assert.False(t, IsNotFound(ErrStatus(401, []byte("token not found"))))
+	assert.False(t, IsNotFound(ErrStatus(500, []byte("not found"))))
+	assert.False(t, IsNotFound(ErrStatus(400, []byte("bad request"))))
+	assert.False(t, IsNotFound(errors.New("dial tcp: connection refused")))
+}
@@ -44,6 +44,17 @@ type Framework struct {
 	Name string `json:"name,omitempty" yaml:"name,omitempty"`
 }
 
+// ListClusters - lists all clusters
+func (c *Client) ListClusters(ctx context.Context) ([]*Cluster, error) {
+	clusters := make([]*Cluster, 0)
+	err := c.get(ctx, &clusters, apiClusters)
+	if err != nil {
+		return nil, err
+	}
+
+	return clusters, nil
+}
+
 // GetCluster - retrieves cluster
 func (c *Client) GetCluster(ctx context.Context, name string) (*Cluster, error) {
 	cluster := &Cluster{}
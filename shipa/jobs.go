@@ -19,6 +19,7 @@ type Job struct {
 	Completions  int64           `json:"completions"`
 	Parallelism  int64           `json:"parallelism"`
 	Suspend      bool            `json:"suspend"`
+	Schedule     string          `json:"schedule,omitempty"`
 	Description  string          `json:"description,omitempty"`
 	Team         string          `json:"team,omitempty"`
 	Teams        []string        `json:"teams,omitempty"`
@@ -27,6 +28,29 @@ type Job struct {
 	CreatedAt    string          `json:"createdAt,omitempty"`
 	DeletedAt    string          `json:"deletedAt,omitempty"`
 	UpdatedAt    string          `json:"updatedAt,omitempty"`
+	Status       *JobStatus      `json:"status,omitempty"`
+
+	ConcurrencyPolicy          string `json:"concurrencyPolicy,omitempty"`
+	SuccessfulJobsHistoryLimit *int64 `json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int64 `json:"failedJobsHistoryLimit,omitempty"`
+}
+
+// JobStatus defines current state of the job
+type JobStatus struct {
+	Active         int64           `json:"active"`
+	Succeeded      int64           `json:"succeeded"`
+	Failed         int64           `json:"failed"`
+	StartTime      string          `json:"startTime,omitempty"`
+	CompletionTime string          `json:"completionTime,omitempty"`
+	Conditions     []*JobCondition `json:"conditions,omitempty"`
+}
+
+// JobCondition defines job condition, e.g. Complete or Failed
+type JobCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 // JobCreateRequest defines fields to create Job
@@ -45,6 +69,25 @@ type JobCreateRequest struct {
 	Team         string `json:"team,omitempty" yaml:"team,omitempty"`
 	Type         string `json:"type,omitempty" yaml:"type,omitempty"`
 	Version      string `json:"version,omitempty" yaml:"version,omitempty"`
+
+	// cron jobs
+	Schedule                   string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	ConcurrencyPolicy          string `json:"concurrencyPolicy,omitempty" yaml:"concurrencyPolicy,omitempty"`
+	SuccessfulJobsHistoryLimit *int64 `json:"successfulJobsHistoryLimit,omitempty" yaml:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int64 `json:"failedJobsHistoryLimit,omitempty" yaml:"failedJobsHistoryLimit,omitempty"`
+}
+
+// Job types
+const (
+	JobTypeJob     = "job"
+	JobTypeCronJob = "cronjob"
+)
+
+// SetDefaults - jobs with schedule are cron jobs
+func (r *JobCreateRequest) SetDefaults() {
+	if r.Schedule != "" && r.Type == "" {
+		r.Type = JobTypeCronJob
+	}
 }
 
 // JobPolicy defines restart policy
@@ -57,6 +100,40 @@ type JobContainer struct {
 	Command []string `json:"command" yaml:"command"`
 	Image   string   `json:"image" yaml:"image"`
 	Name    string   `json:"name" yaml:"name"`
+
+	// optional
+	Args         []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Env          []*JobEnv         `json:"env,omitempty" yaml:"env,omitempty"`
+	Resources    *JobResources     `json:"resources,omitempty" yaml:"resources,omitempty"`
+	WorkingDir   string            `json:"workingDir,omitempty" yaml:"workingDir,omitempty"`
+	VolumeMounts []*JobVolumeMount `json:"volumeMounts,omitempty" yaml:"volumeMounts,omitempty"`
+}
+
+// JobEnv defines container env variable, value can be taken from env of a shipa app
+type JobEnv struct {
+	Name       string        `json:"name" yaml:"name"`
+	Value      string        `json:"value" yaml:"value,omitempty"`
+	FromAppEnv *JobEnvAppRef `json:"-" yaml:"fromAppEnv,omitempty"`
+}
+
+// JobEnvAppRef references env variable of a shipa app, the env must be public
+type JobEnvAppRef struct {
+	App  string `yaml:"app"`
+	Name string `yaml:"name"`
+}
+
+// JobResources defines container resource requests and limits, e.g. cpu: 500m, memory: 128Mi
+type JobResources struct {
+	Requests map[string]string `json:"requests,omitempty" yaml:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// JobVolumeMount defines volume mounted into container
+type JobVolumeMount struct {
+	Name      string `json:"name" yaml:"name"`
+	MountPath string `json:"mountPath" yaml:"mountPath"`
+	SubPath   string `json:"subPath,omitempty" yaml:"subPath,omitempty"`
+	ReadOnly  bool   `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
 }
 
 // GetJob - retrieves job by id
@@ -5,6 +5,9 @@ import (
 	"errors"
 )
 
+// ErrPlanNotFound - uses when plan not found
+var ErrPlanNotFound = errors.New("plan not found")
+
 // GetPlan - retrieves plan by name
 func (c *Client) GetPlan(ctx context.Context, name string) (*Plan, error) {
 	plans, err := c.ListPlans(ctx)
@@ -17,7 +20,7 @@ func (c *Client) GetPlan(ctx context.Context, name string) (*Plan, error) {
 		}
 	}
 
-	return nil, errors.New("plan not found")
+	return nil, ErrPlanNotFound
 }
 
 // ListPlans - list all plans
@@ -9,6 +9,17 @@ type Role struct {
 	Description string `json:"description,omitempty"`
 }
 
+// ListRoles - lists all roles
+func (c *Client) ListRoles(ctx context.Context) ([]*Role, error) {
+	roles := make([]*Role, 0)
+	err := c.get(ctx, &roles, apiRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
 // GetRole - retreives role
 func (c *Client) GetRole(ctx context.Context, name string) (*Role, error) {
 	role := &Role{}
@@ -4,14 +4,27 @@ import "context"
 
 // Team - represents Shipa team
 type Team struct {
-	Name string   `json:"name"`
-	Tags []string `json:"tags,omitempty"`
+	Name string   `json:"name" yaml:"name"`
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
 }
 
 // UpdateTeamRequest - request for team update
 type UpdateTeamRequest struct {
-	Name string   `json:"newname,omitempty"`
-	Tags []string `json:"tags,omitempty"`
+	Name string `json:"newname,omitempty"`
+
+	// tags are always sent, an empty list clears them
+	Tags []string `json:"tags"`
+}
+
+// ListTeams - lists all teams
+func (c *Client) ListTeams(ctx context.Context) ([]*Team, error) {
+	teams := make([]*Team, 0)
+	err := c.get(ctx, &teams, apiTeams)
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
 }
 
 // GetTeam - retreives team
@@ -12,8 +12,26 @@ var (
 
 // User - represents Shipa user
 type User struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string      `json:"email"`
+	Password string      `json:"password"`
+	Roles    []*UserRole `json:"roles,omitempty"`
+}
+
+// UserRole - role assigned to user, part of User object
+type UserRole struct {
+	Name         string `json:"name" yaml:"name"`
+	ContextType  string `json:"contexttype,omitempty" yaml:"contextType,omitempty"`
+	ContextValue string `json:"contextvalue,omitempty" yaml:"contextValue,omitempty"`
+}
+
+// HasRole - checks if role is assigned to user
+func (u *User) HasRole(name string) bool {
+	for _, role := range u.Roles {
+		if role != nil && role.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // GetUser - retrieves user
@@ -50,11 +68,18 @@ func (c *Client) CreateUser(ctx context.Context, req *User) error {
 
 // DeleteUser - deletes user
 func (c *Client) DeleteUser(ctx context.Context, email string) error {
-	return nil
+	params := []*QueryParam{
+		{Key: "user", Val: email},
+	}
+	return c.deleteWithParams(ctx, params, apiUsers)
+}
+
+// ListUserRoles - lists roles assigned to user
+func (c *Client) ListUserRoles(ctx context.Context, email string) ([]*UserRole, error) {
+	user, err := c.GetUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
 
-	// TODO: uncomment after delete user will be fixed
-	// params := map[string]string{
-	// 	"email": email,
-	// }
-	// return c.deleteWithParams(ctx, params, apiUsers)
+	return user.Roles, nil
 }
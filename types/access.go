@@ -0,0 +1,56 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+// Role - roles block item of shipa-action.yml.
+// Live permissions are kept when permissions are not declared, an explicit empty list removes all of them
+type Role struct {
+	Name        string   `yaml:"name"`
+	Context     string   `yaml:"context"`
+	Description string   `yaml:"description,omitempty"`
+	Permissions []string `yaml:"permissions,omitempty"`
+}
+
+// ToShipaRole - converts to shipa role without permissions
+func (r *Role) ToShipaRole() *shipa.Role {
+	return &shipa.Role{
+		Name:        r.Name,
+		Context:     r.Context,
+		Description: r.Description,
+	}
+}
+
+// RoleBinding - role-bindings block item of shipa-action.yml, assigns role to users
+type RoleBinding struct {
+	Role  string   `yaml:"role"`
+	Users []string `yaml:"users"`
+}
+
+// Users - users block of shipa-action.yml
+type Users struct {
+	Members []*UserMember `yaml:"members"`
+	Prune   bool          `yaml:"prune,omitempty"`
+}
+
+// UserMember - part of Users object
+type UserMember struct {
+	Email    string `yaml:"email"`
+	Password string `yaml:"password,omitempty"`
+}
+
+// ToShipaUser - converts to shipa user, password supports the same sources as cluster credentials
+func (m *UserMember) ToShipaUser() (*shipa.User, error) {
+	password, err := resolveSecret(m.Password, false)
+	if err != nil {
+		return nil, fmt.Errorf("user %s password: %v", m.Email, err)
+	}
+
+	return &shipa.User{
+		Email:    m.Email,
+		Password: password,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package types
+
+import "github.com/brunoa19/shipa-github-actions/shipa"
+
+// AppCname - app-cname block of shipa-action.yml
+type AppCname struct {
+	App       string   `yaml:"app"`
+	Cname     string   `yaml:"cname,omitempty"`
+	Encrypted bool     `yaml:"encrypted,omitempty"`
+	Cnames    []*Cname `yaml:"cnames,omitempty"`
+}
+
+// Cname - part of AppCname object
+type Cname struct {
+	Cname     string `yaml:"cname"`
+	Encrypted bool   `yaml:"encrypted"`
+}
+
+// ToShipaCnames - returns all declared cnames, the single cname form goes first
+func (a *AppCname) ToShipaCnames() []*shipa.AppCname {
+	var result []*shipa.AppCname
+	if a.Cname != "" {
+		result = append(result, &shipa.AppCname{
+			App:       a.App,
+			Cname:     a.Cname,
+			Encrypted: a.Encrypted,
+		})
+	}
+
+	for _, cname := range a.Cnames {
+		if cname == nil || cname.Cname == "" {
+			continue
+		}
+		result = append(result, &shipa.AppCname{
+			App:       a.App,
+			Cname:     cname.Cname,
+			Encrypted: cname.Encrypted,
+		})
+	}
+
+	return result
+}
+
+// Prune - undeclared cnames are removed only when the list form is used,
+// so the single cname form keeps working for apps that get cnames from several places
+func (a *AppCname) Prune() bool {
+	return len(a.Cnames) > 0
+}
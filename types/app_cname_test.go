@@ -0,0 +1,33 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AppCname_ToShipaCnames(t *testing.T) {
+	cnames := &AppCname{
+		App:       "api",
+		Cname:     "api.acme.io",
+		Encrypted: true,
+		Cnames: []*Cname{
+			{Cname: "www.acme.io"},
+			nil,
+			{Cname: ""},
+			{Cname: "admin.acme.io", Encrypted: true},
+		},
+	}
+
+	assert.Equal(t, []*shipa.AppCname{
+		{App: "api", Cname: "api.acme.io", Encrypted: true},
+		{App: "api", Cname: "www.acme.io"},
+		{App: "api", Cname: "admin.acme.io", Encrypted: true},
+	}, cnames.ToShipaCnames())
+	assert.True(t, cnames.Prune())
+
+	single := &AppCname{App: "api", Cname: "api.acme.io"}
+	assert.Equal(t, []*shipa.AppCname{{App: "api", Cname: "api.acme.io"}}, single.ToShipaCnames())
+	assert.False(t, single.Prune())
+}
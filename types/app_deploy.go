@@ -0,0 +1,79 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+// AppDeploy - app-deploy block of shipa-action.yml
+type AppDeploy struct {
+	shipa.AppDeploy `yaml:",inline"`
+	PreDeploy       *Job `yaml:"preDeploy,omitempty"`
+	PostDeploy      *Job `yaml:"postDeploy,omitempty"`
+}
+
+// SetDefaults - sets deploy defaults and fills hook jobs from the deploy:
+// name, framework, team and image of the app are used unless set in the hook
+func (a *AppDeploy) SetDefaults() {
+	a.AppDeploy.SetDefaults()
+	a.setHookDefaults(a.PreDeploy, "pre-deploy")
+	a.setHookDefaults(a.PostDeploy, "post-deploy")
+}
+
+// SetHookOwner sets framework and team of hooks which are not set in the hook or in appConfig,
+// used for redeploys of existing apps, which have no appConfig
+func (a *AppDeploy) SetHookOwner(framework, team string) {
+	for _, hook := range []*Job{a.PreDeploy, a.PostDeploy} {
+		if hook == nil {
+			continue
+		}
+		if hook.Framework == "" {
+			hook.Framework = framework
+		}
+		if hook.Team == "" {
+			hook.Team = team
+		}
+	}
+}
+
+func (a *AppDeploy) setHookDefaults(hook *Job, stage string) {
+	if hook == nil {
+		return
+	}
+
+	if hook.Name == "" {
+		hook.Name = fmt.Sprintf("%s-%s", a.App, stage)
+	}
+
+	if a.AppConfig != nil {
+		if hook.Framework == "" {
+			hook.Framework = a.AppConfig.Framework
+		}
+		if hook.Team == "" {
+			hook.Team = a.AppConfig.Team
+		}
+	}
+
+	if hook.Policy == nil {
+		hook.Policy = &shipa.JobPolicy{RestartPolicy: "Never"}
+	}
+
+	for _, container := range hook.Containers {
+		if container == nil {
+			continue
+		}
+		if container.Image == "" {
+			container.Image = a.Image
+		}
+		if container.Name == "" {
+			container.Name = stage
+		}
+	}
+
+	// hooks always run to completion
+	if hook.Run == nil {
+		hook.Run = &JobRun{}
+	}
+	hook.SetDefaults()
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AppDeploy_SetDefaults(t *testing.T) {
+	deploy := &AppDeploy{
+		AppDeploy: shipa.AppDeploy{
+			App:       "api",
+			Image:     "acme/api:v2",
+			AppConfig: &shipa.AppDeployConfig{Framework: "dev", Team: "backend"},
+		},
+		PreDeploy: &Job{JobCreateRequest: shipa.JobCreateRequest{
+			Containers: []*shipa.JobContainer{{Command: []string{"migrate", "up"}}},
+		}},
+		PostDeploy: &Job{
+			JobCreateRequest: shipa.JobCreateRequest{
+				Name:       "smoke",
+				Framework:  "qa",
+				Policy:     &shipa.JobPolicy{RestartPolicy: "OnFailure"},
+				Containers: []*shipa.JobContainer{{Name: "smoke", Image: "acme/smoke:v1"}},
+			},
+			Run: &JobRun{Delete: true},
+		},
+	}
+
+	deploy.SetDefaults()
+	deploy.SetHookOwner("live", "live-team")
+
+	assert.Equal(t, &Job{
+		JobCreateRequest: shipa.JobCreateRequest{
+			Name:       "api-pre-deploy",
+			Framework:  "dev",
+			Team:       "backend",
+			Policy:     &shipa.JobPolicy{RestartPolicy: "Never"},
+			Containers: []*shipa.JobContainer{{Name: "pre-deploy", Image: "acme/api:v2", Command: []string{"migrate", "up"}}},
+		},
+		Run: &JobRun{},
+	}, deploy.PreDeploy)
+
+	assert.Equal(t, &Job{
+		JobCreateRequest: shipa.JobCreateRequest{
+			Name:       "smoke",
+			Framework:  "qa",
+			Team:       "backend",
+			Policy:     &shipa.JobPolicy{RestartPolicy: "OnFailure"},
+			Containers: []*shipa.JobContainer{{Name: "smoke", Image: "acme/smoke:v1"}},
+		},
+		Run: &JobRun{Delete: true},
+	}, deploy.PostDeploy)
+
+	// redeploy of an existing app has no appConfig, owner of the live app is used
+	deploy = &AppDeploy{
+		AppDeploy: shipa.AppDeploy{App: "api", Image: "acme/api:v3"},
+		PreDeploy: &Job{JobCreateRequest: shipa.JobCreateRequest{
+			Containers: []*shipa.JobContainer{{}},
+		}},
+	}
+	deploy.SetDefaults()
+	assert.Empty(t, deploy.PreDeploy.Framework)
+
+	deploy.SetHookOwner("live", "live-team")
+	assert.Equal(t, "live", deploy.PreDeploy.Framework)
+	assert.Equal(t, "live-team", deploy.PreDeploy.Team)
+}
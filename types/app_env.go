@@ -0,0 +1,299 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported env file formats
+const (
+	EnvFormatDotenv = "dotenv"
+	EnvFormatJSON   = "json"
+	EnvFormatYAML   = "yaml"
+)
+
+// AppEnv - app-env block of shipa-action.yml
+type AppEnv struct {
+	App       string          `yaml:"app"`
+	Envs      []*shipa.AppEnv `yaml:"envs,omitempty"`
+	FromFile  []*EnvFile      `yaml:"fromFile,omitempty"`
+	NoRestart bool            `yaml:"norestart"`
+	Private   bool            `yaml:"private"`
+}
+
+// EnvFile - file with env variables, part of AppEnv object
+type EnvFile struct {
+	Path    string `yaml:"path"`
+	Format  string `yaml:"format,omitempty"`
+	Private bool   `yaml:"private"`
+}
+
+type appEnvValue struct {
+	env     *shipa.AppEnv
+	private bool
+}
+
+// ToShipaAppEnvs merges env files with inline envs and returns requests grouped by private flag.
+// Files are applied in the listed order, so later files override earlier ones, and inline envs override all files.
+func (a *AppEnv) ToShipaAppEnvs() ([]*shipa.CreateAppEnv, error) {
+	var order []string
+	values := make(map[string]*appEnvValue)
+	set := func(env *shipa.AppEnv, private bool) {
+		if _, ok := values[env.Name]; !ok {
+			order = append(order, env.Name)
+		}
+		// copied, so decrypting the requests does not change the declared envs
+		copied := *env
+		values[env.Name] = &appEnvValue{env: &copied, private: private}
+	}
+
+	for _, file := range a.FromFile {
+		if file == nil {
+			continue
+		}
+
+		envs, err := file.read()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, env := range envs {
+			set(env, file.Private)
+		}
+	}
+
+	for _, env := range a.Envs {
+		if env != nil {
+			set(env, a.Private)
+		}
+	}
+
+	var public, private []*shipa.AppEnv
+	for _, name := range order {
+		value := values[name]
+		if value.private {
+			private = append(private, value.env)
+		} else {
+			public = append(public, value.env)
+		}
+	}
+
+	var result []*shipa.CreateAppEnv
+	if len(public) > 0 {
+		result = append(result, &shipa.CreateAppEnv{
+			App:       a.App,
+			Envs:      public,
+			NoRestart: a.NoRestart,
+		})
+	}
+
+	if len(private) > 0 {
+		result = append(result, &shipa.CreateAppEnv{
+			App:       a.App,
+			Envs:      private,
+			NoRestart: a.NoRestart,
+			Private:   true,
+		})
+	}
+
+	// every request restarts the app, so only the last one is allowed to do it
+	for i := 0; i < len(result)-1; i++ {
+		result[i].NoRestart = true
+	}
+
+	return result, nil
+}
+
+func (f *EnvFile) format() string {
+	if f.Format != "" {
+		return strings.ToLower(f.Format)
+	}
+
+	switch strings.ToLower(filepath.Ext(f.Path)) {
+	case ".json":
+		return EnvFormatJSON
+	case ".yaml", ".yml":
+		return EnvFormatYAML
+	default:
+		return EnvFormatDotenv
+	}
+}
+
+func (f *EnvFile) read() ([]*shipa.AppEnv, error) {
+	data, err := readFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load env file %s: %v", f.Path, err)
+	}
+
+	var envs []*shipa.AppEnv
+	switch format := f.format(); format {
+	case EnvFormatDotenv:
+		envs, err = parseDotenv(data)
+	case EnvFormatJSON:
+		envs, err = parseJSONEnvs(data)
+	case EnvFormatYAML:
+		envs, err = parseYAMLEnvs(data)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse env file %s: %v", f.Path, err)
+	}
+
+	return envs, nil
+}
+
+func parseDotenv(data []byte) ([]*shipa.AppEnv, error) {
+	var envs []*shipa.AppEnv
+	for i, line := range strings.Split(string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", i+1)
+		}
+
+		name := strings.TrimSpace(line[:idx])
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", i+1, name)
+		}
+
+		value, err := parseDotenvValue(strings.TrimSpace(line[idx+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		envs = append(envs, &shipa.AppEnv{Name: name, Value: value})
+	}
+
+	return envs, nil
+}
+
+func parseDotenvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], checkDotenvRest(raw[end+2:])
+
+	case '"':
+		var sb strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch raw[i] {
+			case '\\':
+				if i+1 == len(raw) {
+					return "", fmt.Errorf("unterminated double-quoted value")
+				}
+				i++
+				switch raw[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				case 'r':
+					sb.WriteByte('\r')
+				default:
+					sb.WriteByte(raw[i])
+				}
+			case '"':
+				return sb.String(), checkDotenvRest(raw[i+1:])
+			default:
+				sb.WriteByte(raw[i])
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	}
+
+	if idx := strings.Index(raw, " #"); idx >= 0 {
+		raw = raw[:idx]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// checkDotenvRest makes sure only a comment follows a quoted value
+func checkDotenvRest(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected characters after quoted value: %q", rest)
+	}
+	return nil
+}
+
+func parseJSONEnvs(data []byte) ([]*shipa.AppEnv, error) {
+	values := make(map[string]interface{})
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapToEnvs(values)
+}
+
+func parseYAMLEnvs(data []byte) ([]*shipa.AppEnv, error) {
+	values := make(map[string]interface{})
+	err := yaml.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapToEnvs(values)
+}
+
+func mapToEnvs(values map[string]interface{}) ([]*shipa.AppEnv, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	envs := make([]*shipa.AppEnv, 0, len(names))
+	for _, name := range names {
+		value, err := scalarToString(values[name])
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %v", name, err)
+		}
+		envs = append(envs, &shipa.AppEnv{Name: name, Value: value})
+	}
+
+	return envs, nil
+}
+
+func scalarToString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("only scalar values are supported, got %T", value)
+	}
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseDotenv(t *testing.T) {
+	data := []byte(`
+# comment
+export FOO=bar
+EMPTY=
+SPACED = value with spaces # trailing comment
+SINGLE='raw \n value'
+DOUBLE="line1\nline2" # comment
+`)
+
+	envs, err := parseDotenv(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []*shipa.AppEnv{
+		{Name: "FOO", Value: "bar"},
+		{Name: "EMPTY", Value: ""},
+		{Name: "SPACED", Value: "value with spaces"},
+		{Name: "SINGLE", Value: `raw \n value`},
+		{Name: "DOUBLE", Value: "line1\nline2"},
+	}, envs)
+
+	_, err = parseDotenv([]byte("NOT_A_PAIR"))
+	assert.EqualError(t, err, "line 1: expected KEY=VALUE")
+}
+
+func Test_AppEnv_ToShipaAppEnvs(t *testing.T) {
+	dir := t.TempDir()
+	dotenv := filepath.Join(dir, ".env")
+	secrets := filepath.Join(dir, "secrets.json")
+	assert.NoError(t, ioutil.WriteFile(dotenv, []byte("A=from-dotenv\nB=from-dotenv\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(secrets, []byte(`{"B": "from-json", "PORT": 8080}`), 0600))
+
+	appEnv := &AppEnv{
+		App: "app",
+		FromFile: []*EnvFile{
+			{Path: dotenv},
+			{Path: secrets, Private: true},
+		},
+		Envs: []*shipa.AppEnv{
+			{Name: "A", Value: "inline"},
+		},
+		NoRestart: true,
+	}
+
+	reqs, err := appEnv.ToShipaAppEnvs()
+	assert.NoError(t, err)
+	assert.Equal(t, []*shipa.CreateAppEnv{
+		{
+			App:       "app",
+			Envs:      []*shipa.AppEnv{{Name: "A", Value: "inline"}},
+			NoRestart: true,
+		},
+		{
+			App:       "app",
+			Envs:      []*shipa.AppEnv{{Name: "B", Value: "from-json"}, {Name: "PORT", Value: "8080"}},
+			NoRestart: true,
+			Private:   true,
+		},
+	}, reqs)
+
+	appEnv.NoRestart = false
+	reqs, err = appEnv.ToShipaAppEnvs()
+	assert.NoError(t, err)
+	assert.Len(t, reqs, 2)
+	assert.True(t, reqs[0].NoRestart, "only the last request may restart the app")
+	assert.False(t, reqs[1].NoRestart)
+
+	appEnv.FromFile = nil
+	reqs, err = appEnv.ToShipaAppEnvs()
+	assert.NoError(t, err)
+	assert.Len(t, reqs, 1)
+	assert.False(t, reqs[0].NoRestart)
+}
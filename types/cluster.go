@@ -5,29 +5,34 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-    "strings"
 
-    "github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/brunoa19/shipa-github-actions/shipa"
 )
 
 type Cluster struct {
-	Name      string            `json:"name" yaml:"name"`
-	Endpoint  *ClusterEndpoint  `json:"endpoint" yaml:"endpoint"`
-	Resources *ClusterResources `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Name         string            `json:"name" yaml:"name"`
+	Kubeconfig   string            `json:"-" yaml:"kubeconfig,omitempty"`
+	Context      string            `json:"-" yaml:"context,omitempty"`
+	ExpiryWindow string            `json:"-" yaml:"certExpiryWindow,omitempty"`
+	Endpoint     *ClusterEndpoint  `json:"endpoint" yaml:"endpoint"`
+	Resources    *ClusterResources `json:"resources,omitempty" yaml:"resources,omitempty"`
 }
 
 func (c *Cluster) ToShipaCluster() (*shipa.Cluster, error) {
 	var frameworks []*shipa.Framework
+	input := *c
 	if c.Resources != nil && c.Resources.Frameworks != nil {
 		for _, name := range c.Resources.Frameworks.Name {
 			frameworks = append(frameworks, &shipa.Framework{
 				Name: name,
 			})
 		}
-		c.Resources.Frameworks = nil
+		resources := *c.Resources
+		resources.Frameworks = nil
+		input.Resources = &resources
 	}
 
-	rawJson, err := json.Marshal(c)
+	rawJson, err := json.Marshal(&input)
 	if err != nil {
 		return nil, err
 	}
@@ -46,25 +51,40 @@ func (c *Cluster) ToShipaCluster() (*shipa.Cluster, error) {
 	}
 
 	if cluster.Endpoint != nil {
-		cluster.Endpoint.Token = useFileOrValue(cluster.Endpoint.Token)
-		cluster.Endpoint.Certificate = useFileOrValue(cluster.Endpoint.Certificate)
-		cluster.Endpoint.ClientCertificate = useFileOrValue(cluster.Endpoint.ClientCertificate)
-		cluster.Endpoint.ClientKey = useFileOrValue(cluster.Endpoint.ClientKey)
+		// password was never read from a file, so only the other fields fall back to paths
+		secrets := []struct {
+			field        string
+			value        *string
+			pathFallback bool
+		}{
+			{"token", &cluster.Endpoint.Token, true},
+			{"caCert", &cluster.Endpoint.Certificate, true},
+			{"clientCert", &cluster.Endpoint.ClientCertificate, true},
+			{"clientKey", &cluster.Endpoint.ClientKey, true},
+			{"password", &cluster.Endpoint.Password, false},
+		}
+		for _, secret := range secrets {
+			*secret.value, err = resolveSecret(*secret.value, secret.pathFallback)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint.%s: %v", secret.field, err)
+			}
+		}
+	}
+
+	if c.Kubeconfig != "" {
+		endpoint, err := endpointFromKubeconfig(c.Kubeconfig, c.Context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig %s: %v", c.Kubeconfig, err)
+		}
+		cluster.Endpoint = mergeEndpoint(endpoint, cluster.Endpoint)
 	}
 
 	return cluster, nil
 }
 
-func useFileOrValue(value string) string {
-	if value == "" {
-		return ""
-	}
-
-	data, err := readFile(value)
-	if err != nil {
-		return value
-	}
-	return strings.Trim(string(data), " \n\t")
+// PruneFrameworks - frameworks missing in the list are detached from the cluster only when prune is set
+func (c *Cluster) PruneFrameworks() bool {
+	return c.Resources != nil && c.Resources.Frameworks != nil && c.Resources.Frameworks.Prune
 }
 
 func readFile(path string) ([]byte, error) {
@@ -112,5 +132,6 @@ type IngressController struct {
 
 // Framework - part of ClusterResources object
 type Framework struct {
-	Name []string `json:"name,omitempty" yaml:"name,omitempty"`
+	Name  []string `json:"name,omitempty" yaml:"name,omitempty"`
+	Prune bool     `json:"-" yaml:"prune,omitempty"`
 }
@@ -0,0 +1,143 @@
+package types
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+// DefaultCertExpiryWindow - certificates and tokens expiring sooner are rejected
+const DefaultCertExpiryWindow = 7 * 24 * time.Hour
+
+// CertExpiryWindow - returns configured expiry window, "0" disables the window, but expired credentials are still rejected
+func (c *Cluster) CertExpiryWindow() (time.Duration, error) {
+	if c.ExpiryWindow == "" {
+		return DefaultCertExpiryWindow, nil
+	}
+
+	window, err := time.ParseDuration(c.ExpiryWindow)
+	if err != nil {
+		return 0, fmt.Errorf("invalid certExpiryWindow: %v", err)
+	}
+	return window, nil
+}
+
+// CheckCredentials - parses PEM certificates, checks their validity period, verifies client key matches client certificate
+// and checks expiry of JWT tokens
+func CheckCredentials(endpoint *shipa.ClusterEndpoint, window time.Duration, now time.Time) error {
+	if endpoint == nil {
+		return nil
+	}
+
+	var msgs []string
+	if endpoint.Certificate != "" {
+		if err := checkCertificates(endpoint.Certificate, window, now); err != nil {
+			msgs = append(msgs, fmt.Sprintf("caCert: %v", err))
+		}
+	}
+
+	if endpoint.ClientCertificate != "" {
+		if err := checkCertificates(endpoint.ClientCertificate, window, now); err != nil {
+			msgs = append(msgs, fmt.Sprintf("clientCert: %v", err))
+		}
+	}
+
+	switch {
+	case endpoint.ClientCertificate != "" && endpoint.ClientKey == "":
+		msgs = append(msgs, "clientKey: required when clientCert is set")
+	case endpoint.ClientCertificate == "" && endpoint.ClientKey != "":
+		msgs = append(msgs, "clientCert: required when clientKey is set")
+	case endpoint.ClientCertificate != "":
+		if _, err := tls.X509KeyPair([]byte(endpoint.ClientCertificate), []byte(endpoint.ClientKey)); err != nil {
+			msgs = append(msgs, fmt.Sprintf("clientKey: does not match clientCert: %v", err))
+		}
+	}
+
+	if endpoint.Token != "" {
+		if err := checkJWT(endpoint.Token, window, now); err != nil {
+			msgs = append(msgs, fmt.Sprintf("token: %v", err))
+		}
+	}
+
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+func checkCertificates(data string, window time.Duration, now time.Time) error {
+	rest := []byte(data)
+	found := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		found = true
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse certificate: %v", err)
+		}
+
+		if err = checkValidity(cert.Subject.String(), cert.NotBefore, cert.NotAfter, window, now); err != nil {
+			return err
+		}
+	}
+
+	if !found {
+		return errors.New("no PEM encoded certificate found")
+	}
+	return nil
+}
+
+func checkValidity(name string, notBefore, notAfter time.Time, window time.Duration, now time.Time) error {
+	switch {
+	case !notBefore.IsZero() && now.Before(notBefore):
+		return fmt.Errorf("%s is not valid before %s", name, notBefore.Format(time.RFC3339))
+	case now.After(notAfter):
+		return fmt.Errorf("%s expired at %s", name, notAfter.Format(time.RFC3339))
+	case now.Add(window).After(notAfter):
+		return fmt.Errorf("%s expires at %s, within %s", name, notAfter.Format(time.RFC3339), window)
+	}
+	return nil
+}
+
+// checkJWT checks exp and nbf claims of JWT-shaped tokens, other tokens are ignored
+func checkJWT(token string, window time.Duration, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	var claims struct {
+		ExpiresAt *float64 `json:"exp"`
+		NotBefore *float64 `json:"nbf"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	var notBefore time.Time
+	if claims.NotBefore != nil {
+		notBefore = time.Unix(int64(*claims.NotBefore), 0).UTC()
+	}
+	return checkValidity("token", notBefore, time.Unix(int64(*claims.ExpiresAt), 0).UTC(), window, now)
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateCert(t *testing.T, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
+
+func Test_CheckCredentials(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	validCert, validKey := generateCert(t, now.Add(30*24*time.Hour))
+	soonCert, _ := generateCert(t, now.Add(24*time.Hour))
+	_, otherKey := generateCert(t, now.Add(30*24*time.Hour))
+
+	err := CheckCredentials(&shipa.ClusterEndpoint{
+		Certificate:       validCert,
+		ClientCertificate: validCert,
+		ClientKey:         validKey,
+	}, DefaultCertExpiryWindow, now)
+	assert.NoError(t, err)
+
+	err = CheckCredentials(&shipa.ClusterEndpoint{Certificate: soonCert}, DefaultCertExpiryWindow, now)
+	assert.Error(t, err)
+	assert.NoError(t, CheckCredentials(&shipa.ClusterEndpoint{Certificate: soonCert}, 0, now))
+
+	err = CheckCredentials(&shipa.ClusterEndpoint{ClientCertificate: validCert, ClientKey: otherKey}, 0, now)
+	assert.Error(t, err)
+
+	err = CheckCredentials(&shipa.ClusterEndpoint{Certificate: "not a pem"}, 0, now)
+	assert.EqualError(t, err, "caCert: no PEM encoded certificate found")
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, now.Add(-time.Hour).Unix())))
+	err = CheckCredentials(&shipa.ClusterEndpoint{Token: "e30." + payload + ".sig"}, 0, now)
+	assert.Error(t, err)
+	assert.NoError(t, CheckCredentials(&shipa.ClusterEndpoint{Token: "opaque-token"}, 0, now))
+}
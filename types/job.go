@@ -0,0 +1,49 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+// Defaults of job run mode
+const (
+	DefaultJobRunTimeout      = 30 * time.Minute
+	DefaultJobRunPollInterval = 5 * time.Second
+)
+
+// Job - job block of shipa-action.yml
+type Job struct {
+	shipa.JobCreateRequest `yaml:",inline"`
+	Run                    *JobRun `yaml:"run,omitempty"`
+}
+
+// JobRun - when set, the job is created, awaited and optionally deleted, part of Job object
+type JobRun struct {
+	Timeout      string `yaml:"timeout,omitempty"`
+	PollInterval string `yaml:"pollInterval,omitempty"`
+	Delete       bool   `yaml:"delete,omitempty"`
+}
+
+// Durations - returns run timeout and poll interval
+func (r *JobRun) Durations() (time.Duration, time.Duration, error) {
+	timeout, err := parseDuration(r.Timeout, DefaultJobRunTimeout)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid run.timeout: %v", err)
+	}
+
+	pollInterval, err := parseDuration(r.PollInterval, DefaultJobRunPollInterval)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid run.pollInterval: %v", err)
+	}
+
+	return timeout, pollInterval, nil
+}
+
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(value)
+}
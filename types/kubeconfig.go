@@ -0,0 +1,197 @@
+package types
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+	"gopkg.in/yaml.v2"
+)
+
+type kubeconfig struct {
+	CurrentContext string               `yaml:"current-context"`
+	Clusters       []*kubeconfigCluster `yaml:"clusters"`
+	Contexts       []*kubeconfigContext `yaml:"contexts"`
+	Users          []*kubeconfigUser    `yaml:"users"`
+}
+
+type kubeconfigCluster struct {
+	Name    string `yaml:"name"`
+	Cluster struct {
+		Server                   string `yaml:"server"`
+		CertificateAuthority     string `yaml:"certificate-authority"`
+		CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	} `yaml:"cluster"`
+}
+
+type kubeconfigContext struct {
+	Name    string `yaml:"name"`
+	Context struct {
+		Cluster string `yaml:"cluster"`
+		User    string `yaml:"user"`
+	} `yaml:"context"`
+}
+
+type kubeconfigUser struct {
+	Name string `yaml:"name"`
+	User struct {
+		Token                 string      `yaml:"token"`
+		TokenFile             string      `yaml:"tokenFile"`
+		ClientCertificate     string      `yaml:"client-certificate"`
+		ClientCertificateData string      `yaml:"client-certificate-data"`
+		ClientKey             string      `yaml:"client-key"`
+		ClientKeyData         string      `yaml:"client-key-data"`
+		Username              string      `yaml:"username"`
+		Password              string      `yaml:"password"`
+		Exec                  interface{} `yaml:"exec"`
+		AuthProvider          interface{} `yaml:"auth-provider"`
+	} `yaml:"user"`
+}
+
+// endpointFromKubeconfig extracts cluster endpoint from the given context, current context is used when name is empty
+func endpointFromKubeconfig(path, contextName string) (*shipa.ClusterEndpoint, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &kubeconfig{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %v", err)
+	}
+
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+	if contextName == "" {
+		return nil, errors.New("kubeconfig has no current-context, set context explicitly")
+	}
+
+	var context *kubeconfigContext
+	for _, c := range config.Contexts {
+		if c != nil && c.Name == contextName {
+			context = c
+		}
+	}
+	if context == nil {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	var cluster *kubeconfigCluster
+	for _, c := range config.Clusters {
+		if c != nil && c.Name == context.Context.Cluster {
+			cluster = c
+		}
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster %q of context %q not found in kubeconfig", context.Context.Cluster, contextName)
+	}
+
+	var user *kubeconfigUser
+	for _, u := range config.Users {
+		if u != nil && u.Name == context.Context.User {
+			user = u
+		}
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %q of context %q not found in kubeconfig", context.Context.User, contextName)
+	}
+
+	// relative paths in kubeconfig are resolved against its directory
+	dir := filepath.Dir(path)
+	endpoint := &shipa.ClusterEndpoint{
+		Addresses: []string{cluster.Cluster.Server},
+		Username:  user.User.Username,
+		Password:  user.User.Password,
+	}
+
+	endpoint.Certificate, err = kubeconfigValue(dir, cluster.Cluster.CertificateAuthorityData, cluster.Cluster.CertificateAuthority)
+	if err != nil {
+		return nil, fmt.Errorf("certificate-authority: %v", err)
+	}
+
+	endpoint.ClientCertificate, err = kubeconfigValue(dir, user.User.ClientCertificateData, user.User.ClientCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("client-certificate: %v", err)
+	}
+
+	endpoint.ClientKey, err = kubeconfigValue(dir, user.User.ClientKeyData, user.User.ClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("client-key: %v", err)
+	}
+
+	endpoint.Token = user.User.Token
+	if endpoint.Token == "" && user.User.TokenFile != "" {
+		endpoint.Token, err = kubeconfigValue(dir, "", user.User.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("tokenFile: %v", err)
+		}
+	}
+
+	if endpoint.Token == "" && endpoint.ClientCertificate == "" && endpoint.Password == "" &&
+		(user.User.Exec != nil || user.User.AuthProvider != nil) {
+		return nil, fmt.Errorf("user %q uses exec or auth-provider credentials, which are not supported, use a token or client certificate", user.Name)
+	}
+
+	return endpoint, nil
+}
+
+// kubeconfigValue returns decoded *-data field, or content of the referenced file
+func kubeconfigValue(dir, data, path string) (string, error) {
+	if data != "" {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return "", fmt.Errorf("invalid base64 data: %v", err)
+		}
+		return strings.TrimSpace(string(decoded)), nil
+	}
+
+	if path == "" {
+		return "", nil
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	content, err := readFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// mergeEndpoint overrides endpoint fields with the explicitly set ones
+func mergeEndpoint(base, override *shipa.ClusterEndpoint) *shipa.ClusterEndpoint {
+	if override == nil {
+		return base
+	}
+
+	result := *base
+	if len(override.Addresses) > 0 {
+		result.Addresses = override.Addresses
+	}
+	if override.Certificate != "" {
+		result.Certificate = override.Certificate
+	}
+	if override.ClientCertificate != "" {
+		result.ClientCertificate = override.ClientCertificate
+	}
+	if override.ClientKey != "" {
+		result.ClientKey = override.ClientKey
+	}
+	if override.Token != "" {
+		result.Token = override.Token
+	}
+	if override.Username != "" {
+		result.Username = override.Username
+	}
+	if override.Password != "" {
+		result.Password = override.Password
+	}
+	return &result
+}
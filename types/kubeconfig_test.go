@@ -0,0 +1,61 @@
+package types
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Cluster_ToShipaCluster_kubeconfig(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("file-token\n"), 0600))
+
+	ca := base64.StdEncoding.EncodeToString([]byte("ca-cert"))
+	config := `
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com
+    certificate-authority-data: ` + ca + `
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: dev
+  context: {cluster: dev-cluster, user: dev-user}
+- name: prod
+  context: {cluster: prod-cluster, user: prod-user}
+users:
+- name: dev-user
+  user:
+    tokenFile: token
+- name: prod-user
+  user:
+    token: prod-token
+`
+	path := filepath.Join(dir, "kubeconfig")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(config), 0600))
+
+	cluster, err := (&Cluster{Name: "dev", Kubeconfig: path}).ToShipaCluster()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://dev.example.com"}, cluster.Endpoint.Addresses)
+	assert.Equal(t, "ca-cert", cluster.Endpoint.Certificate)
+	assert.Equal(t, "file-token", cluster.Endpoint.Token)
+
+	cluster, err = (&Cluster{
+		Name:       "prod",
+		Kubeconfig: path,
+		Context:    "prod",
+		Endpoint:   &ClusterEndpoint{Addresses: []string{"https://10.0.0.1"}},
+	}).ToShipaCluster()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://10.0.0.1"}, cluster.Endpoint.Addresses)
+	assert.Equal(t, "prod-token", cluster.Endpoint.Token)
+
+	_, err = (&Cluster{Name: "x", Kubeconfig: path, Context: "missing"}).ToShipaCluster()
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Secret source prefixes
+const (
+	secretFile    = "file:"
+	secretEnv     = "env:"
+	secretBase64  = "base64:"
+	secretLiteral = "literal:"
+)
+
+// resolveSecret reads value from explicit source: "file:path", "env:NAME", "base64:data" or "literal:value",
+// anything else is a literal. With pathFallback unprefixed values starting with "./", "../" or "/" are read as files,
+// for backward compatibility of cluster credentials, a missing file is an error instead of silently using the path as value.
+func resolveSecret(value string, pathFallback bool) (string, error) {
+	switch {
+	case value == "":
+		return "", nil
+
+	case strings.HasPrefix(value, secretFile):
+		return readSecretFile(strings.TrimPrefix(value, secretFile))
+
+	case strings.HasPrefix(value, secretEnv):
+		name := strings.TrimPrefix(value, secretEnv)
+		data, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("env %s not set", name)
+		}
+		return strings.TrimSpace(data), nil
+
+	case strings.HasPrefix(value, secretBase64):
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(value, secretBase64)))
+		if err != nil {
+			return "", fmt.Errorf("invalid base64 value: %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	case strings.HasPrefix(value, secretLiteral):
+		return strings.TrimPrefix(value, secretLiteral), nil
+
+	case pathFallback && looksLikePath(value):
+		data, err := readSecretFile(value)
+		if err != nil {
+			return "", fmt.Errorf("%v; values starting with './', '../' or '/' are read as files, use the %q prefix for literal values",
+				err, secretLiteral)
+		}
+		return data, nil
+
+	default:
+		return value, nil
+	}
+}
+
+func readSecretFile(path string) (string, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(string(data), " \n\t"), nil
+}
+
+func looksLikePath(value string) bool {
+	if strings.ContainsAny(value, " \n\t") {
+		return false
+	}
+	return strings.HasPrefix(value, "./") || strings.HasPrefix(value, "../") || strings.HasPrefix(value, "/")
+}
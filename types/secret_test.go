@@ -0,0 +1,61 @@
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_resolveSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(" from-file\n"), 0600))
+	assert.NoError(t, os.Setenv("SHIPA_TEST_TOKEN", "from-env"))
+	defer os.Unsetenv("SHIPA_TEST_TOKEN")
+
+	// relative paths are resolved from the working directory
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "infra"), 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "infra", "token"), []byte("from-relative-file\n"), 0600))
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		value        string
+		pathFallback bool
+		want         string
+		wantErr      bool
+	}{
+		{value: "", want: ""},
+		{value: "literal-token", want: "literal-token"},
+		{value: "file:" + path, want: "from-file"},
+		{value: "file:infra/token", want: "from-relative-file"},
+		{value: path, pathFallback: true, want: "from-file"},
+		{value: "./infra/token", pathFallback: true, want: "from-relative-file"},
+		{value: "infra/token", pathFallback: true, want: "infra/token"},
+		{value: "./infra/token", want: "./infra/token"},
+		{value: "p/ss", want: "p/ss"},
+		{value: "literal:./infra/token", pathFallback: true, want: "./infra/token"},
+		{value: "literal:env:HOME", want: "env:HOME"},
+		{value: "env:SHIPA_TEST_TOKEN", want: "from-env"},
+		{value: "base64:ZnJvbS1iYXNlNjQ=", want: "from-base64"},
+		{value: "file:./missing/token", wantErr: true},
+		{value: "./missing/token", pathFallback: true, wantErr: true},
+		{value: "env:SHIPA_TEST_MISSING", wantErr: true},
+		{value: "base64:!!!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := resolveSecret(tt.value, tt.pathFallback)
+		if tt.wantErr {
+			assert.Error(t, err, tt.value)
+			continue
+		}
+		assert.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/brunoa19/shipa-github-actions/shipa"
+)
+
+func usersCommand(args []string, debug bool) error {
+	if len(args) == 0 {
+		return errors.New("users: subcommand required, available: roles")
+	}
+
+	switch args[0] {
+	case "roles":
+		return userRolesCommand(args[1:], debug)
+	default:
+		return fmt.Errorf("users: unknown subcommand: %s", args[0])
+	}
+}
+
+func userRolesCommand(args []string, debug bool) error {
+	fs := flag.NewFlagSet("users roles", flag.ExitOnError)
+	email := fs.String("email", "", "User email")
+	output := fs.String("output", outputTable, "Output format: table, json or yaml")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *email == "" {
+		return errors.New("users roles: -email is required")
+	}
+
+	client, err := newClient(debug)
+	if err != nil {
+		return err
+	}
+
+	roles, err := client.ListUserRoles(context.TODO(), *email)
+	if err != nil {
+		return fmt.Errorf("failed to list roles of shipa user %s: %v", *email, err)
+	}
+
+	switch *output {
+	case outputTable:
+		return printUserRolesTable(roles)
+	case outputJSON, outputYAML:
+		return printObject(*output, roles)
+	default:
+		return fmt.Errorf("unknown output format: %s", *output)
+	}
+}
+
+func printUserRolesTable(roles []*shipa.UserRole) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tCONTEXT\tCONTEXT VALUE")
+	for _, role := range roles {
+		if role != nil {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", role.Name, role.ContextType, role.ContextValue)
+		}
+	}
+	return w.Flush()
+}